@@ -1,116 +1,169 @@
-package di
-
-import (
-	"context"
-	"sync"
-)
-
-// Container is a simple dependency injection container.
-type Container struct {
-	mu      *sync.RWMutex
-	srvs    map[string]interface{}
-	srvConf map[string]*ServiceConfig
-}
-
-// NewContainer returns a new Container.
-func NewContainer() *Container {
-	return &Container{
-		mu:      &sync.RWMutex{},
-		srvs:    make(map[string]interface{}),
-		srvConf: make(map[string]*ServiceConfig),
-	}
-}
-
-// BuildFunc is a function used to build a service.
-type BuildFunc func(ctn *Container) interface{}
-
-// DisposeFunc is a function used to clean and dispose a service.
-type DisposeFunc func(ctx context.Context, i interface{})
-
-// ServiceConfig represents a service within the Container.
-type ServiceConfig struct {
-	Singleton bool
-	Build     BuildFunc
-	Dispose   DisposeFunc
-}
-
-// GetService attempts to resolve a service by name.
-func (ctn *Container) GetService(name string) interface{} {
-	ctn.mu.RLock()
-	defer ctn.mu.RUnlock()
-
-	conf, ok := ctn.srvConf[name]
-	if !ok {
-		panic("unable to resolve service: " + name)
-	}
-
-	srv, ok := ctn.srvs[name]
-	if conf.Singleton && ok {
-		return srv
-	}
-
-	impl := conf.Build(ctn)
-
-	if conf.Singleton {
-		ctn.srvs[name] = impl
-	}
-
-	return impl
-}
-
-// AddService adds a new service definition to the container.
-func (ctn *Container) AddService(name string, builder BuildFunc) *ServiceBuilder {
-	return ctn.addService(name, false, builder)
-}
-
-// AddSingleton adds a new singleton service definition to the container.
-func (ctn *Container) AddSingleton(name string, builder BuildFunc) *ServiceBuilder {
-	return ctn.addService(name, true, builder)
-}
-
-func (ctn *Container) addService(name string, singleton bool, builder BuildFunc) *ServiceBuilder {
-	ctn.mu.Lock()
-	defer ctn.mu.Unlock()
-
-	s := &ServiceConfig{
-		Singleton: singleton,
-		Build:     builder,
-	}
-	ctn.srvConf[name] = s
-
-	return &ServiceBuilder{s: s}
-}
-
-// Clean is used to clean up the services in the container. Once,
-// this func has been called, the container can still be used and services
-// built. However, this is intended to be called at the end of a program.
-//
-// If a service has a DisposeFunc, this will be called before it is removed
-// from the container. However, if there is no DisposeFunc, the service will
-// just be removed.
-func (ctn *Container) Clean(ctx context.Context) {
-	ctn.mu.Lock()
-	defer ctn.mu.Unlock()
-
-	for name, value := range ctn.srvs {
-		cnf := ctn.srvConf[name]
-		if cnf.Dispose != nil {
-			cnf.Dispose(ctx, value)
-		}
-
-		delete(ctn.srvs, name)
-	}
-}
-
-// ServiceBuilder is a type used to provide a fluent-like API
-// when adding a service to the container.
-type ServiceBuilder struct {
-	s *ServiceConfig
-}
-
-// Dispose is used to configure a function used to dispose the service.
-func (b *ServiceBuilder) Dispose(f DisposeFunc) *ServiceBuilder {
-	b.s.Dispose = f
-
-	return b
-}
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrServiceNotFound is panicked with, wrapped, when a service can
+// not be resolved, as there is no service registered with the name.
+var ErrServiceNotFound = errors.New("unable to resolve service")
+
+// ErrScopedServiceOutsideScope is panicked with, wrapped, when a
+// scoped service is resolved from a Container, rather than a Scope.
+var ErrScopedServiceOutsideScope = errors.New("unable to resolve scoped service outside of a scope")
+
+// Container is a simple dependency injection container.
+type Container struct {
+	mu      *sync.RWMutex
+	srvs    map[string]interface{}
+	srvConf map[string]*ServiceConfig
+
+	// The scope the container resolves scoped services from,
+	// if the container was created by a Scope.
+	scope *Scope
+}
+
+// NewContainer returns a new Container.
+func NewContainer() *Container {
+	return &Container{
+		mu:      &sync.RWMutex{},
+		srvs:    make(map[string]interface{}),
+		srvConf: make(map[string]*ServiceConfig),
+	}
+}
+
+// BuildFunc is a function used to build a service.
+type BuildFunc func(ctn *Container) interface{}
+
+// DisposeFunc is a function used to clean and dispose a service.
+type DisposeFunc func(ctx context.Context, i interface{})
+
+// ServiceConfig represents a service within the Container. A service
+// is either a singleton, scoped, or otherwise transient.
+type ServiceConfig struct {
+	Singleton bool
+	Scoped    bool
+	Build     BuildFunc
+	Dispose   DisposeFunc
+
+	// Held while building a singleton, so it's only built once.
+	mu sync.Mutex
+}
+
+// GetService attempts to resolve a service by name.
+//
+// The container is only read locked while looking up the service, so
+// services are built without holding the lock, allowing their BuildFunc
+// to resolve dependencies.
+func (ctn *Container) GetService(name string) interface{} {
+	ctn.mu.RLock()
+	conf, ok := ctn.srvConf[name]
+	srv, built := ctn.srvs[name]
+	ctn.mu.RUnlock()
+
+	if !ok {
+		panic(fmt.Errorf("%w: %s", ErrServiceNotFound, name))
+	}
+
+	switch {
+	case conf.Scoped:
+		if ctn.scope == nil {
+			panic(fmt.Errorf("%w: %s", ErrScopedServiceOutsideScope, name))
+		}
+
+		return ctn.scope.getService(name, conf)
+	case conf.Singleton && built:
+		return srv
+	case conf.Singleton:
+		return ctn.buildSingleton(name, conf)
+	}
+
+	return conf.Build(ctn)
+}
+
+// buildSingleton is used to build and store the singleton service with the
+// given name. Concurrent callers wait for the service to be built, rather than
+// building their own.
+func (ctn *Container) buildSingleton(name string, conf *ServiceConfig) interface{} {
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+
+	// The service may have been built while waiting for the lock.
+	ctn.mu.RLock()
+	srv, ok := ctn.srvs[name]
+	ctn.mu.RUnlock()
+
+	if ok {
+		return srv
+	}
+
+	impl := conf.Build(ctn)
+
+	ctn.mu.Lock()
+	ctn.srvs[name] = impl
+	ctn.mu.Unlock()
+
+	return impl
+}
+
+// AddService adds a new service definition to the container.
+func (ctn *Container) AddService(name string, builder BuildFunc) *ServiceBuilder {
+	return ctn.addService(name, &ServiceConfig{Build: builder})
+}
+
+// AddSingleton adds a new singleton service definition to the container.
+func (ctn *Container) AddSingleton(name string, builder BuildFunc) *ServiceBuilder {
+	return ctn.addService(name, &ServiceConfig{Singleton: true, Build: builder})
+}
+
+// AddScoped adds a new scoped service definition to the container. A scoped
+// service is built once per Scope, and can only be resolved from a Scope.
+func (ctn *Container) AddScoped(name string, builder BuildFunc) *ServiceBuilder {
+	return ctn.addService(name, &ServiceConfig{Scoped: true, Build: builder})
+}
+
+func (ctn *Container) addService(name string, s *ServiceConfig) *ServiceBuilder {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.srvConf[name] = s
+
+	return &ServiceBuilder{s: s}
+}
+
+// Clean is used to clean up the services in the container. Once,
+// this func has been called, the container can still be used and services
+// built. However, this is intended to be called at the end of a program.
+//
+// If a service has a DisposeFunc, this will be called before it is removed
+// from the container. However, if there is no DisposeFunc, the service will
+// just be removed.
+func (ctn *Container) Clean(ctx context.Context) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	for name, value := range ctn.srvs {
+		cnf := ctn.srvConf[name]
+		if cnf.Dispose != nil {
+			cnf.Dispose(ctx, value)
+		}
+
+		delete(ctn.srvs, name)
+	}
+}
+
+// ServiceBuilder is a type used to provide a fluent-like API
+// when adding a service to the container.
+type ServiceBuilder struct {
+	s *ServiceConfig
+}
+
+// Dispose is used to configure a function used to dispose the service.
+func (b *ServiceBuilder) Dispose(f DisposeFunc) *ServiceBuilder {
+	b.s.Dispose = f
+
+	return b
+}
@@ -1,146 +1,196 @@
-package di
-
-import (
-	"context"
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-)
-
-func TestContainer_GetNonExistantService_Panics(t *testing.T) {
-	ctn := NewContainer()
-
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("Expected panic")
-		}
-	}()
-
-	_ = ctn.GetService("myService")
-}
-
-func TestContainer_AddService(t *testing.T) {
-	builder := func(ctn *Container) interface{} {
-		return nil
-	}
-	name := "myService"
-
-	ctn := NewContainer()
-	sb := ctn.AddService(name, builder)
-	assert.NotNil(t, sb)
-
-	srv, ok := ctn.srvConf[name]
-	assert.True(t, ok)
-	assert.False(t, srv.Singleton)
-}
-
-func TestContainer_AddSingleton(t *testing.T) {
-	builder := func(ctn *Container) interface{} {
-		return nil
-	}
-	name := "myService"
-
-	ctn := NewContainer()
-	sb := ctn.AddSingleton(name, builder)
-	assert.NotNil(t, sb)
-
-	srv, ok := ctn.srvConf[name]
-	assert.True(t, ok)
-	assert.True(t, srv.Singleton)
-}
-
-func TestContainer_SingletonNotRecreated(t *testing.T) {
-	ctn := NewContainer()
-
-	ctn.srvConf["test"] = &ServiceConfig{
-		Singleton: true,
-		Build: func(ctn *Container) interface{} {
-			srvValue := "My super cool service"
-			return &srvValue
-		},
-	}
-
-	srv := ctn.GetService("test")
-	srv2 := ctn.GetService("test")
-
-	if srv != srv2 {
-		t.Error("Expected the services to be equal")
-	}
-}
-
-func TestContainer_TransientIsRecreated(t *testing.T) {
-	ctn := NewContainer()
-
-	ctn.srvConf["test"] = &ServiceConfig{
-		Singleton: false,
-		Build: func(ctn *Container) interface{} {
-			srvValue := "My super cool service"
-			return &srvValue
-		},
-	}
-
-	srv := ctn.GetService("test")
-	srv2 := ctn.GetService("test")
-
-	if srv == srv2 {
-		t.Error("Expected the services to not be equal")
-	}
-}
-
-func TestContainer_WithDependentService(t *testing.T) {
-	ctn := NewContainer()
-
-	ctn.srvConf["text1"] = &ServiceConfig{
-		Singleton: false,
-		Build: func(ctn *Container) interface{} {
-			return "World"
-		},
-	}
-
-	ctn.srvConf["text2"] = &ServiceConfig{
-		Singleton: false,
-		Build: func(ctn *Container) interface{} {
-			w := ctn.GetService("text1").(string)
-			return "Hello " + w
-		},
-	}
-
-	t2 := ctn.GetService("text2")
-	assert.Equal(t, "Hello World", t2)
-}
-
-func TestContainer_Clean(t *testing.T) {
-	hasBeenDisposed := false
-	testCtx := context.Background()
-	testValue := "My String"
-
-	ctn := NewContainer()
-	ctn.AddSingleton("MyService", func(ctn *Container) interface{} {
-		return testValue
-	}).Dispose(func(ctx context.Context, i interface{}) {
-		assert.Equal(t, testCtx, ctx)
-		assert.Equal(t, testValue, i)
-
-		// Proves that the dispose has only been called once.
-		assert.False(t, hasBeenDisposed)
-
-		hasBeenDisposed = true
-	})
-
-	// Builds the service
-	_ = ctn.GetService("MyService")
-
-	ctn.Clean(testCtx)
-
-	assert.True(t, hasBeenDisposed)
-	assert.Nil(t, ctn.srvs["MyService"])
-}
-
-func TestServiceBuilder_Dispose(t *testing.T) {
-	s := &ServiceConfig{Dispose: nil}
-	b := &ServiceBuilder{s: s}
-
-	r := b.Dispose(func(ctx context.Context, i interface{}) {})
-	assert.Equal(t, b, r)
-	assert.NotNil(t, s.Dispose)
-}
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_GetNonExistantService_Panics(t *testing.T) {
+	ctn := NewContainer()
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Errorf("Expected panic")
+		}
+
+		err := r.(error)
+		assert.True(t, errors.Is(err, ErrServiceNotFound))
+		assert.EqualError(t, err, "unable to resolve service: myService")
+	}()
+
+	_ = ctn.GetService("myService")
+}
+
+func TestContainer_AddService(t *testing.T) {
+	builder := func(ctn *Container) interface{} {
+		return nil
+	}
+	name := "myService"
+
+	ctn := NewContainer()
+	sb := ctn.AddService(name, builder)
+	assert.NotNil(t, sb)
+
+	srv, ok := ctn.srvConf[name]
+	assert.True(t, ok)
+	assert.False(t, srv.Singleton)
+}
+
+func TestContainer_AddSingleton(t *testing.T) {
+	builder := func(ctn *Container) interface{} {
+		return nil
+	}
+	name := "myService"
+
+	ctn := NewContainer()
+	sb := ctn.AddSingleton(name, builder)
+	assert.NotNil(t, sb)
+
+	srv, ok := ctn.srvConf[name]
+	assert.True(t, ok)
+	assert.True(t, srv.Singleton)
+}
+
+func TestContainer_SingletonNotRecreated(t *testing.T) {
+	ctn := NewContainer()
+
+	ctn.srvConf["test"] = &ServiceConfig{
+		Singleton: true,
+		Build: func(ctn *Container) interface{} {
+			srvValue := "My super cool service"
+			return &srvValue
+		},
+	}
+
+	srv := ctn.GetService("test")
+	srv2 := ctn.GetService("test")
+
+	if srv != srv2 {
+		t.Error("Expected the services to be equal")
+	}
+}
+
+func TestContainer_SingletonBuiltOnceConcurrently(t *testing.T) {
+	builds := int32(0)
+
+	ctn := NewContainer()
+	ctn.AddSingleton("test", func(ctn *Container) interface{} {
+		atomic.AddInt32(&builds, 1)
+		srvValue := "My super cool service"
+		return &srvValue
+	})
+
+	var wg sync.WaitGroup
+	srvs := make([]interface{}, 50)
+	for i := range srvs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			srvs[i] = ctn.GetService("test")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), builds)
+	for _, srv := range srvs {
+		assert.Same(t, srvs[0], srv)
+	}
+}
+
+func BenchmarkContainer_GetService_ConcurrentSingleton(b *testing.B) {
+	ctn := NewContainer()
+	ctn.AddSingleton("test", func(ctn *Container) interface{} {
+		srvValue := "My super cool service"
+		return &srvValue
+	})
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = ctn.GetService("test")
+		}
+	})
+}
+
+func TestContainer_TransientIsRecreated(t *testing.T) {
+	ctn := NewContainer()
+
+	ctn.srvConf["test"] = &ServiceConfig{
+		Singleton: false,
+		Build: func(ctn *Container) interface{} {
+			srvValue := "My super cool service"
+			return &srvValue
+		},
+	}
+
+	srv := ctn.GetService("test")
+	srv2 := ctn.GetService("test")
+
+	if srv == srv2 {
+		t.Error("Expected the services to not be equal")
+	}
+}
+
+func TestContainer_WithDependentService(t *testing.T) {
+	ctn := NewContainer()
+
+	ctn.srvConf["text1"] = &ServiceConfig{
+		Singleton: false,
+		Build: func(ctn *Container) interface{} {
+			return "World"
+		},
+	}
+
+	ctn.srvConf["text2"] = &ServiceConfig{
+		Singleton: false,
+		Build: func(ctn *Container) interface{} {
+			w := ctn.GetService("text1").(string)
+			return "Hello " + w
+		},
+	}
+
+	t2 := ctn.GetService("text2")
+	assert.Equal(t, "Hello World", t2)
+}
+
+func TestContainer_Clean(t *testing.T) {
+	hasBeenDisposed := false
+	testCtx := context.Background()
+	testValue := "My String"
+
+	ctn := NewContainer()
+	ctn.AddSingleton("MyService", func(ctn *Container) interface{} {
+		return testValue
+	}).Dispose(func(ctx context.Context, i interface{}) {
+		assert.Equal(t, testCtx, ctx)
+		assert.Equal(t, testValue, i)
+
+		// Proves that the dispose has only been called once.
+		assert.False(t, hasBeenDisposed)
+
+		hasBeenDisposed = true
+	})
+
+	// Builds the service
+	_ = ctn.GetService("MyService")
+
+	ctn.Clean(testCtx)
+
+	assert.True(t, hasBeenDisposed)
+	assert.Nil(t, ctn.srvs["MyService"])
+}
+
+func TestServiceBuilder_Dispose(t *testing.T) {
+	s := &ServiceConfig{Dispose: nil}
+	b := &ServiceBuilder{s: s}
+
+	r := b.Dispose(func(ctx context.Context, i interface{}) {})
+	assert.Equal(t, b, r)
+	assert.NotNil(t, s.Dispose)
+}
@@ -0,0 +1,87 @@
+package di
+
+import (
+	"context"
+	"sync"
+)
+
+// Scope is used to resolve scoped services, where each scoped service
+// is built once per Scope. Singleton and transient services are resolved
+// from the Container the Scope was created from.
+type Scope struct {
+	mu   *sync.Mutex
+	ctn  *Container
+	srvs map[string]interface{}
+}
+
+// CreateScope returns a new Scope, created from the container.
+func (ctn *Container) CreateScope() *Scope {
+	s := &Scope{
+		mu:   &sync.Mutex{},
+		srvs: make(map[string]interface{}),
+	}
+
+	// The scope's container shares the services of ctn, but resolves
+	// scoped services from the scope. It's given to each BuildFunc,
+	// so the dependencies of scoped services are resolved in the scope.
+	s.ctn = &Container{
+		mu:      ctn.mu,
+		srvs:    ctn.srvs,
+		srvConf: ctn.srvConf,
+		scope:   s,
+	}
+
+	return s
+}
+
+// GetService attempts to resolve a service by name.
+func (s *Scope) GetService(name string) interface{} {
+	return s.ctn.GetService(name)
+}
+
+// getService returns the scope's instance of the scoped service
+// with the given name, building it if it hasn't already been.
+func (s *Scope) getService(name string, conf *ServiceConfig) interface{} {
+	s.mu.Lock()
+	srv, ok := s.srvs[name]
+	s.mu.Unlock()
+
+	if ok {
+		return srv
+	}
+
+	// The service is built without holding the lock, as
+	// its dependencies may be scoped services themselves.
+	impl := conf.Build(s.ctn)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if srv, ok := s.srvs[name]; ok {
+		return srv
+	}
+
+	s.srvs[name] = impl
+
+	return impl
+}
+
+// Dispose is used to clean up the scoped services built in the Scope,
+// using each service's DisposeFunc, if it has one. Once disposed, the
+// Scope can still be used, however, scoped services will be built again.
+func (s *Scope) Dispose(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, value := range s.srvs {
+		s.ctn.mu.RLock()
+		cnf := s.ctn.srvConf[name]
+		s.ctn.mu.RUnlock()
+
+		if cnf.Dispose != nil {
+			cnf.Dispose(ctx, value)
+		}
+
+		delete(s.srvs, name)
+	}
+}
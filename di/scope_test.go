@@ -0,0 +1,87 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_AddScoped(t *testing.T) {
+	builder := func(ctn *Container) interface{} {
+		return nil
+	}
+	name := "myService"
+
+	ctn := NewContainer()
+	sb := ctn.AddScoped(name, builder)
+	assert.NotNil(t, sb)
+
+	srv, ok := ctn.srvConf[name]
+	assert.True(t, ok)
+	assert.True(t, srv.Scoped)
+	assert.False(t, srv.Singleton)
+}
+
+func TestScope_GetService(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddSingleton("singleton", func(ctn *Container) interface{} {
+		v := "singleton"
+		return &v
+	})
+	ctn.AddScoped("scoped", func(ctn *Container) interface{} {
+		v := "scoped"
+		return &v
+	})
+	ctn.AddScoped("dependent", func(ctn *Container) interface{} {
+		return []interface{}{ctn.GetService("scoped"), ctn.GetService("singleton")}
+	})
+
+	s1 := ctn.CreateScope()
+	s2 := ctn.CreateScope()
+
+	// Scoped services should be reused within a scope, but not across scopes.
+	v1 := s1.GetService("scoped")
+	assert.Same(t, v1, s1.GetService("scoped"))
+	assert.NotSame(t, v1, s2.GetService("scoped"))
+
+	// Dependencies of scoped services should be resolved in the scope.
+	deps := s1.GetService("dependent").([]interface{})
+	assert.Same(t, v1, deps[0])
+	assert.Same(t, ctn.GetService("singleton"), deps[1])
+
+	t.Run("Where Resolved Outside Of Scope", func(t *testing.T) {
+		assert.PanicsWithError(t, "unable to resolve scoped service outside of a scope: scoped", func() {
+			_ = ctn.GetService("scoped")
+		})
+	})
+}
+
+func TestScope_Dispose(t *testing.T) {
+	disposed := make([]interface{}, 0)
+	testCtx := context.Background()
+
+	ctn := NewContainer()
+	ctn.AddScoped("scoped", func(ctn *Container) interface{} {
+		v := "scoped"
+		return &v
+	}).Dispose(func(ctx context.Context, i interface{}) {
+		assert.Equal(t, testCtx, ctx)
+		disposed = append(disposed, i)
+	})
+	ctn.AddSingleton("singleton", func(ctn *Container) interface{} {
+		return "singleton"
+	}).Dispose(func(ctx context.Context, i interface{}) {
+		disposed = append(disposed, i)
+	})
+
+	s := ctn.CreateScope()
+	v := s.GetService("scoped")
+	_ = s.GetService("singleton")
+
+	s.Dispose(testCtx)
+
+	// Only the scoped service should be disposed.
+	assert.Equal(t, []interface{}{v}, disposed)
+	assert.NotSame(t, v, s.GetService("scoped"))
+}
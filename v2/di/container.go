@@ -1,143 +1,1513 @@
-package di
-
-import (
-	"context"
-	"fmt"
-	"reflect"
-	"sync"
-)
-
-// Container is a simple dependency injection container.
-type Container struct {
-	mu       *sync.RWMutex
-	services []*Service
-}
-
-// NewContainer returns a new Container.
-func NewContainer() *Container {
-	return &Container{
-		mu:       &sync.RWMutex{},
-		services: make([]*Service, 0),
-	}
-}
-
-// GetService is used to resolve a service by name. If the service
-// does not exist, it will panic.
-//
-// This function panics instead of returning an error, so that it
-// can be called inline, without the extra bulk of handling an error.
-func (ctn *Container) GetService(name string) interface{} {
-	ctn.mu.RLock()
-	defer ctn.mu.RUnlock()
-
-	for _, s := range ctn.services {
-		if s.name != name {
-			continue
-		}
-
-		v, err := s.build(ctn.getService)
-		if err != nil {
-			panic(fmt.Errorf("container: failed to build %s, %v", s.Name(), err))
-		}
-
-		return v
-	}
-
-	panic(fmt.Errorf("container: could not find service, %s", name))
-}
-
-// getService is an internal function used to resolve a service by its type.
-// This is used by Service.build() to resolve dependencies.
-func (ctn *Container) getService(t reflect.Type) (interface{}, error) {
-	ctn.mu.RLock()
-	defer ctn.mu.RUnlock()
-
-	for _, s := range ctn.services {
-		if s.typ != t {
-			continue
-		}
-
-		v, err := s.build(ctn.getService)
-		if err != nil {
-			return nil, fmt.Errorf("container: failed to build %s, %v", s.Name(), err)
-		}
-
-		return v, nil
-	}
-
-	return nil, fmt.Errorf("container: failed to resolve %s", t.Name())
-}
-
-// GetServices is used to retrievean array of services of a given type.
-func (ctn *Container) GetServices(t reflect.Type) []interface{} {
-	ctn.mu.RLock()
-	defer ctn.mu.RUnlock()
-
-	svcs := make([]interface{}, 0)
-	for _, s := range ctn.services {
-		if s.typ != t {
-			continue
-		}
-		v, err := s.build(ctn.getService)
-		if err != nil {
-			panic(fmt.Errorf("container: failed to build %s, %v", s.Name(), err))
-		}
-		svcs = append(svcs, v)
-	}
-	return svcs
-}
-
-// AddService adds a new service definition to the container. The ctor argument
-// should be the constructor function, which is used to build the service.
-//
-// A constructor function can contain an range of arguments, however, either
-// return an interface, or an interface and error: func() MyService or
-// func() (MyService, error).
-func (ctn *Container) AddService(ctor interface{}) *Service {
-	ctn.mu.Lock()
-	defer ctn.mu.Unlock()
-
-	s := NewService(ctor)
-	ctn.services = append(ctn.services, s)
-	return s
-}
-
-// Clean is used to clean up the services in the container. Once,
-// this func has been called, the container can still be used and services
-// built. However, this is intended to be called at the end of a program.
-//
-// If a service has a DisposeFunc, this will be called before it is removed
-// from the container. However, if there is no DisposeFunc, the service will
-// just be removed.
-func (ctn *Container) Clean(ctx context.Context) {
-	ctn.mu.Lock()
-	defer ctn.mu.Unlock()
-
-	for _, s := range ctn.services {
-		s.Dispose(ctx)
-	}
-}
-
-func (ctn *Container) getServiceInfo(name string) *Service {
-	ctn.mu.RLock()
-	defer ctn.mu.RUnlock()
-
-	for _, s := range ctn.services {
-		if s.name == name {
-			return s
-		}
-	}
-
-	panic(fmt.Errorf("container: could not find service, %s", name))
-}
-
-// CreateScope is used to create a scoped service provider.
-func (ctn *Container) CreateScope() *Scope {
-	return ctn.CreateScopeWithContext(context.Background())
-}
-
-// CreateScopeWithContext is used to create a scope service provider,
-// with the given context.Context configured.
-func (ctn *Container) CreateScopeWithContext(ctx context.Context) *Scope {
-	return newScope(ctn, ctx)
-}
+package di
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Container is a simple dependency injection container.
+type Container struct {
+	mu       *sync.RWMutex
+	services []*Service
+
+	// Indexes of the services by type and name, used to resolve
+	// services without scanning all of them. Each slice is in
+	// registration order.
+	byType map[reflect.Type][]*Service
+	byName map[string][]*Service
+
+	// Whether constructor panics should propagate,
+	// rather than be recovered into build errors.
+	noRecover bool
+
+	// Whether service names must be unique.
+	strict bool
+
+	// The container to fall back to, when a service
+	// can not be resolved from this container.
+	parent *Container
+
+	// The context.Context provided to constructors
+	// resolved from the container, outside of a Scope.
+	ctx context.Context
+
+	// Used to log the construction of services, if not nil.
+	logger *slog.Logger
+
+	// Called before each service is built, if not nil.
+	onBuild func(ctx context.Context, name string) (done func(err error))
+
+	// Whether services can no longer be registered.
+	frozen bool
+
+	// Called each time a service is resolved, if not nil.
+	onResolve func(name string, lifetime ServiceLifetime, cached bool)
+
+	// Middleware wrapping GetService, in the order it was added.
+	middleware []func(next func(name string) interface{}) func(name string) interface{}
+
+	// The maximum depth of a chain of dependencies, if positive.
+	maxDepth int
+
+	// The services started by Start, in the order they were started.
+	started []*Service
+}
+
+// NewContainer returns a new Container, configured with opts.
+func NewContainer(opts ...Option) *Container {
+	ctn := &Container{
+		mu:       &sync.RWMutex{},
+		services: make([]*Service, 0),
+		byType:   make(map[reflect.Type][]*Service),
+		byName:   make(map[string][]*Service),
+		ctx:      context.Background(),
+	}
+
+	for _, opt := range opts {
+		opt(ctn)
+	}
+
+	return ctn
+}
+
+// SetRecoverPanics is used to configure whether panics raised by constructors
+// are recovered and returned as build errors, which is the default behaviour.
+// If false, panics propagate as they are, from the constructor call.
+func (ctn *Container) SetRecoverPanics(enabled bool) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.noRecover = !enabled
+}
+
+// SetStrict is used to configure whether the container enforces unique
+// service names. In strict mode, naming a service with a name already used
+// by another service will panic. Otherwise, the service registered last
+// is resolved by the name.
+//
+// As services are named after their type by default, multiple services of
+// the same type can be registered in strict mode, provided they're given
+// distinct names using SetName.
+func (ctn *Container) SetStrict(strict bool) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.strict = strict
+}
+
+// SetOnBuild is used to configure a hook, which is called before each
+// service is built, with the context.Context of the resolution and the
+// service's name. The function it returns, if not nil, is called once
+// the service is built, with the build error, if any. The context given
+// is that which the service's constructor would be given, so is the
+// Scope's context.Context when building a scoped service.
+//
+// This can be used to instrument the construction of services, such as
+// by creating a span for each. Singletons resolved after they are built
+// do not call the hook.
+func (ctn *Container) SetOnBuild(hook func(ctx context.Context, name string) (done func(err error))) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.onBuild = hook
+}
+
+// Freeze is used to prevent any more services being registered in the
+// container, or existing services being renamed or decorated, which will
+// panic once frozen. Services can still be resolved.
+//
+// This is intended to be called once an application has registered its
+// services, to catch services being registered after resolution begins.
+func (ctn *Container) Freeze() *Container {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.frozen = true
+	return ctn
+}
+
+// checkFrozen panics if the container is frozen, describing the change
+// attempted by action. The caller must hold the write lock.
+func (ctn *Container) checkFrozen(action string) {
+	if ctn.frozen {
+		panic(fmt.Errorf("container: can not %s, as the container is frozen", action))
+	}
+}
+
+// SetOnResolve is used to configure a callback, which is called each time a
+// service is resolved, either directly or as a dependency, with its name and
+// lifetime. The cached argument is true if an existing singleton or scoped
+// instance is used, rather than building one.
+//
+// The callback is not called while the container is locked, so can resolve
+// services. However, scoped services are resolved while their Scope is locked,
+// so services should not be resolved from the Scope by the callback.
+func (ctn *Container) SetOnResolve(callback func(name string, lifetime ServiceLifetime, cached bool)) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.onResolve = callback
+}
+
+// GetService is used to resolve a service by name. If the service
+// does not exist, it will panic.
+//
+// This function panics instead of returning an error, so that it
+// can be called inline, without the extra bulk of handling an error.
+func (ctn *Container) GetService(name string) interface{} {
+	return ctn.GetServiceWithContext(ctn.ctx, name)
+}
+
+// GetServiceWithContext is used to resolve a service by name, in the same way
+// as GetService, where context.Context parameters of the service, and of its
+// dependencies, are given ctx, rather than the container's base context.Context.
+// This allows constructors to observe the cancellation of a request, without
+// creating a Scope. Note that singletons retain the context they're built with.
+func (ctn *Container) GetServiceWithContext(ctx context.Context, name string) interface{} {
+	get := ctn.withMiddleware(func(name string) interface{} {
+		return ctn.getServiceByName(ctx, name)
+	})
+
+	return get(name)
+}
+
+// withMiddleware returns get wrapped by the middleware added by Use.
+func (ctn *Container) withMiddleware(get func(name string) interface{}) func(name string) interface{} {
+	ctn.mu.RLock()
+	mws := ctn.middleware
+	ctn.mu.RUnlock()
+
+	for i := len(mws) - 1; i >= 0; i-- {
+		get = mws[i](get)
+	}
+
+	return get
+}
+
+// getServiceByType is used to resolve the service of type t, in the same way
+// as GetService. The middleware added by Use is given the service's name,
+// however, the service is resolved by its type, unless the middleware
+// substitutes another name, so types with the same name, from different
+// packages, are resolved independently.
+func (ctn *Container) getServiceByType(t reflect.Type) interface{} {
+	return ctn.getServiceByTypeWithContext(ctn.ctx, t)
+}
+
+// getServiceByTypeWithContext is used to resolve the service of type t, in
+// the same way as getServiceByType, with the context.Context ctx.
+func (ctn *Container) getServiceByTypeWithContext(ctx context.Context, t reflect.Type) interface{} {
+	name := serviceName(t)
+	if s, _ := ctn.getServiceInfoByType(t); s != nil {
+		name = s.Name()
+	}
+
+	get := ctn.withMiddleware(func(n string) interface{} {
+		if n != name {
+			return ctn.getServiceByName(ctx, n)
+		}
+
+		v, err := ctn.resolve(ctx, nil, t)
+		if err != nil {
+			panic(err)
+		}
+
+		return v
+	})
+
+	return get(name)
+}
+
+// TryGetService is used to resolve a service by name, in the same way as
+// GetService, but returns an error rather than panicking. The error can
+// be identified using errors.Is, such as with ErrServiceNotFound, or
+// ErrBuildFailed.
+func (ctn *Container) TryGetService(name string) (v interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+
+			err = e
+		}
+	}()
+
+	return ctn.GetService(name), nil
+}
+
+// Use is used to add middleware, which wraps each call to GetService. The
+// middleware is given the next function in the chain, and returns a function
+// which resolves a service by name, so can substitute or transform the service,
+// or recover a panic. Middleware is called in the order it was added, where
+// the first added is the outermost.
+//
+// Dependencies resolved while building a service are not passed through
+// the middleware.
+func (ctn *Container) Use(mw func(next func(name string) interface{}) func(name string) interface{}) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.middleware = append(ctn.middleware, mw)
+}
+
+// getServiceByName is used to resolve a service by name, with the
+// context.Context ctx, without the middleware added by Use.
+func (ctn *Container) getServiceByName(ctx context.Context, name string) interface{} {
+	ctn.mu.RLock()
+	svcs := ctn.byName[name]
+	ctn.mu.RUnlock()
+
+	if len(svcs) > 0 {
+		v, err := ctn.build(ctx, nil, svcs[len(svcs)-1])
+		if err != nil {
+			panic(err)
+		}
+
+		return v
+	}
+
+	if ctn.parent != nil && ctn.parent.lookupName(name) != nil {
+		return ctn.parent.GetServiceWithContext(ctx, name)
+	}
+
+	panic(ctn.notFound(name))
+}
+
+// Resolve is used to resolve a service by its type, falling back to the
+// parent container, if there is one. This implements Resolver.
+func (ctn *Container) Resolve(t reflect.Type) (interface{}, error) {
+	return ctn.resolve(ctn.ctx, nil, t)
+}
+
+// GetServiceByType is used to resolve a service by its type, in the same way
+// as Resolve, for callers which only have a reflect.Type. If no service is
+// registered as t, but a single service is assignable to it, such as an
+// implementation of an interface not registered using Service.As, that
+// service is resolved instead, or the primary service, if there are several.
+func (ctn *Container) GetServiceByType(t reflect.Type) (interface{}, error) {
+	v, err := ctn.Resolve(t)
+	if !isNotFound(err) {
+		return v, err
+	}
+
+	svcs := ctn.assignableOf(t)
+	switch len(svcs) {
+	case 0:
+		return nil, err
+	case 1:
+		return svcs[0].ctn.build(ctn.ctx, nil, svcs[0])
+	}
+
+	if p := primaryOf(svcs); p != nil {
+		return p.ctn.build(ctn.ctx, nil, p)
+	}
+
+	names := make([]string, len(svcs))
+	for i, s := range svcs {
+		names[i] = s.Name()
+	}
+
+	return nil, fmt.Errorf("%w: %s matches %s", ErrAmbiguousDependency, t.String(), strings.Join(names, ", "))
+}
+
+// Context returns the container's base context.Context, configured
+// using WithContext. This implements Resolver.
+func (ctn *Container) Context() context.Context {
+	return ctn.ctx
+}
+
+// resolver returns the resolver used to resolve the dependencies of the
+// last service in path, with the context.Context ctx, which is passed
+// to Service.build().
+func (ctn *Container) resolver(ctx context.Context, path resolutionPath) resolver {
+	return containerResolver{ctn: ctn, ctx: ctx, path: path}
+}
+
+// resolveNamed is used to resolve a service by its name, in the same way
+// as resolve, where ctx is the context.Context of the resolution and path
+// is the chain of services currently being built.
+func (ctn *Container) resolveNamed(ctx context.Context, path resolutionPath, name string) (interface{}, error) {
+	ctn.mu.RLock()
+	svcs := ctn.byName[name]
+	ctn.mu.RUnlock()
+
+	if len(svcs) > 0 {
+		return ctn.build(ctx, path, svcs[len(svcs)-1])
+	}
+
+	if ctn.parent != nil {
+		return ctn.parent.resolveNamed(ctx, path, name)
+	}
+
+	return nil, &notFoundError{name: name}
+}
+
+// resolve is used to resolve a service by its type, where ctx is the
+// context.Context of the resolution and path is the chain of services
+// currently being built, used to detect cycles. If the service is not
+// found, it is resolved from the parent container.
+func (ctn *Container) resolve(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	if t == contextType {
+		return ctx, nil
+	}
+	if isContainerType(t) {
+		if s, _ := ctn.getServiceInfoByType(t); s == nil {
+			return ctn, nil
+		}
+	}
+	if t == scopeType {
+		if s, _ := ctn.getServiceInfoByType(t); s == nil {
+			return nil, fmt.Errorf("%w, %s", ErrScopedOutsideScope, t.String())
+		}
+	}
+
+	v, err := ctn.resolveLocal(ctx, path, t)
+	if ctn.parent != nil && isNotFound(err) {
+		return ctn.parent.resolve(ctx, path, t)
+	}
+
+	return v, err
+}
+
+// resolveLocal is used to resolve a service by its type,
+// from the services registered in this container.
+func (ctn *Container) resolveLocal(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	if isKeyed(t) {
+		return ctn.getKeyedService(ctx, path, t)
+	}
+
+	ctn.mu.RLock()
+	s, err := ctn.lookup(t)
+
+	// Slices and maps of services can only be resolved from the
+	// parent, if there are none of the element type.
+	slice := t.Kind() == reflect.Slice && (ctn.parent == nil || len(ctn.byType[t.Elem()]) > 0)
+	smap := isServiceMap(t) && (ctn.parent == nil || len(ctn.assignable(t.Elem())) > 0)
+	ctn.mu.RUnlock()
+
+	switch {
+	case err != nil:
+		return nil, err
+	case s != nil:
+		return ctn.build(ctx, path, s)
+	case slice:
+		return ctn.getServiceSlice(ctx, path, t)
+	case smap:
+		return ctn.getServiceMap(ctx, path, t)
+	}
+
+	return nil, &notFoundError{typ: t}
+}
+
+// build is used to build the service s, as a dependency of the last service in path.
+// The container's lock must not be held, so services can be resolved by the
+// constructor and any callbacks.
+//
+// Transient services are only built once per resolution, so where several
+// services in path depend on the same transient service, they share an instance.
+func (ctn *Container) build(ctx context.Context, path resolutionPath, s *Service) (interface{}, error) {
+	if s.lifetime == LifetimeScoped {
+		return nil, fmt.Errorf("%w, %s", ErrScopedOutsideScope, s.Name())
+	}
+
+	if v, ok := path.transient(s); ok {
+		s.resolved(true)
+		return v, nil
+	}
+
+	s.resolved(s.instance() != nil)
+
+	parent := path
+	path, err := ctn.extend(path, s)
+	if err != nil {
+		return nil, err
+	}
+	defer path.complete()
+
+	v, err := s.build(ctn.resolver(ctx, path), len(path)-1)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s, %w", ErrBuildFailed, s.Name(), err)
+	}
+
+	parent.shareTransient(s, v)
+
+	return v, nil
+}
+
+// extend returns a copy of path with s appended, in the same way as
+// resolutionPath.with, returning an error if the container's maximum
+// depth is exceeded.
+func (ctn *Container) extend(path resolutionPath, s *Service) (resolutionPath, error) {
+	if ctn.maxDepth > 0 && len(path) > ctn.maxDepth {
+		return nil, fmt.Errorf("%w: %s is more than %d levels deep", ErrMaxDepthExceeded, s.Name(), ctn.maxDepth)
+	}
+
+	return path.with(s)
+}
+
+// getServiceSlice is used to resolve a slice dependency, of type t, where
+// there is no service of the slice type itself. The slice is populated with
+// every service of the slice's element type, other than those being built in
+// path, and may be empty.
+func (ctn *Container) getServiceSlice(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	et := t.Elem()
+
+	ctn.mu.RLock()
+	elems := path.exclude(ctn.byType[et])
+	ctn.mu.RUnlock()
+
+	svcs := reflect.MakeSlice(t, 0, len(elems))
+	for _, s := range elems {
+		v, err := ctn.build(ctx, path, s)
+		if err != nil {
+			return nil, err
+		}
+
+		svcs = reflect.Append(svcs, valueOf(v, et))
+	}
+
+	return svcs.Interface(), nil
+}
+
+// getServiceMap is used to resolve a map dependency, of type t, where each
+// service assignable to the map's element type is built, as a dependency of
+// the last service in path, and keyed by its name. Services being built in
+// path are left out, in the same way as getServiceSlice.
+func (ctn *Container) getServiceMap(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	ctn.mu.RLock()
+	svcs := path.exclude(ctn.assignable(t.Elem()))
+	ctn.mu.RUnlock()
+
+	return buildServiceMap(t, svcs, func(s *Service) (interface{}, error) {
+		return ctn.build(ctx, path, s)
+	})
+}
+
+// servicesOf returns the services of type t, registered in this container or,
+// if there are none, the nearest parent container with services of type t.
+func (ctn *Container) servicesOf(t reflect.Type) []*Service {
+	ctn.mu.RLock()
+	svcs := ctn.byType[t]
+	ctn.mu.RUnlock()
+
+	if len(svcs) == 0 && ctn.parent != nil {
+		return ctn.parent.servicesOf(t)
+	}
+
+	return svcs
+}
+
+// assignableOf returns the services assignable to the type t, registered in
+// this container or, if there are none, the nearest parent container with
+// services assignable to t.
+func (ctn *Container) assignableOf(t reflect.Type) []*Service {
+	ctn.mu.RLock()
+	svcs := ctn.assignable(t)
+	ctn.mu.RUnlock()
+
+	if len(svcs) == 0 && ctn.parent != nil {
+		return ctn.parent.assignableOf(t)
+	}
+
+	return svcs
+}
+
+// getKeyedService is used to resolve a Keyed dependency, of type t. The
+// service matching the Keyed type and key is built, then wrapped in t.
+func (ctn *Container) getKeyedService(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	kv := reflect.New(t)
+	kd := kv.Interface().(keyedDependency)
+	typ, key := kd.keyed()
+
+	ctn.mu.RLock()
+	svc := ctn.lookupKeyed(typ, key)
+	ctn.mu.RUnlock()
+
+	if svc == nil {
+		return nil, &notFoundError{typ: typ, key: key}
+	}
+
+	v, err := ctn.build(ctx, path, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	kd.set(v)
+	return kv.Elem().Interface(), nil
+}
+
+// GetKeyedService is used to retrieve the service of type t, which has been
+// registered with the given key, using Service.WithKey. If there is no such
+// service in the container, or its parent, or it fails to build, it will panic.
+func (ctn *Container) GetKeyedService(t reflect.Type, key string) interface{} {
+	svc := ctn.keyedOf(t, key)
+	if svc == nil {
+		panic(&notFoundError{typ: t, key: key})
+	}
+
+	v, err := ctn.build(ctn.ctx, nil, svc)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// lookupKeyed returns the service of type t, with the given key, or nil if
+// there isn't one. The caller must hold the read lock.
+func (ctn *Container) lookupKeyed(t reflect.Type, key string) *Service {
+	for _, s := range ctn.byType[t] {
+		if s.key == key {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// keyedOf returns the service of type t, with the given key, registered in
+// this container or, if there isn't one, its parent.
+func (ctn *Container) keyedOf(t reflect.Type, key string) *Service {
+	ctn.mu.RLock()
+	svc := ctn.lookupKeyed(t, key)
+	ctn.mu.RUnlock()
+
+	if svc == nil && ctn.parent != nil {
+		return ctn.parent.keyedOf(t, key)
+	}
+
+	return svc
+}
+
+// GetServices is used to retrieve the services of a given type. Services
+// are returned in the order they were registered, unless an order has been
+// set using Service.WithOrder, in which case they are sorted by their order
+// first. If there are none in the container, those of its nearest parent with
+// services of type t are returned, in the same way as a slice is injected.
+func (ctn *Container) GetServices(t reflect.Type) []interface{} {
+	return ctn.buildAll(ctn.servicesOf(t))
+}
+
+// TryGetServices is used to retrieve the services of a given type, in the
+// same order as GetServices, but rather than panicking if a service fails to
+// build, the services which were built are returned, with an error joining
+// each of the failures. This allows the rest of the services to be used,
+// such as plugins, if one of them is broken.
+func (ctn *Container) TryGetServices(t reflect.Type) ([]interface{}, error) {
+	svcs := ctn.servicesOf(t)
+	vs := make([]interface{}, 0, len(svcs))
+	var errs []error
+	for _, s := range svcs {
+		v, err := ctn.build(ctn.ctx, nil, s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		vs = append(vs, v)
+	}
+
+	return vs, errors.Join(errs...)
+}
+
+// buildAll is used to build each of the services in svcs,
+// in order. If any of the services fail to build, it will panic.
+func (ctn *Container) buildAll(svcs []*Service) []interface{} {
+	vs := make([]interface{}, 0, len(svcs))
+	for _, s := range svcs {
+		v, err := ctn.build(ctn.ctx, nil, s)
+		if err != nil {
+			panic(err)
+		}
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+// GetServicesAssignable is used to retrieve every service assignable to the
+// type t, sorted by their order, in the same way as GetServices. Unlike GetServices, where
+// t is an interface, this includes each service implementing it, regardless
+// of whether it was registered as the interface, using Service.As. If there
+// are none in the container, those of its nearest parent are returned.
+//
+// If any of the services fail to build, it will panic.
+func (ctn *Container) GetServicesAssignable(t reflect.Type) []interface{} {
+	return ctn.buildAll(ctn.assignableOf(t))
+}
+
+// assignable returns the services assignable to the type t, sorted by their
+// order. The caller must hold the read lock.
+func (ctn *Container) assignable(t reflect.Type) []*Service {
+	svcs := make([]*Service, 0)
+	for _, s := range ctn.services {
+		if s.typ.AssignableTo(t) {
+			svcs = append(svcs, s)
+		}
+	}
+	sortByOrder(svcs)
+
+	return svcs
+}
+
+// GetGroup is used to retrieve the services in a given group, sorted by their
+// order, in the same way as GetServices. If there are none in the container, those
+// in the group in its nearest parent are returned. If any of the services
+// fail to build, it will panic.
+func (ctn *Container) GetGroup(group string) []interface{} {
+	return ctn.buildAll(ctn.getGroupInfo(group))
+}
+
+// GetTagged is used to retrieve the services with the given tag, in the
+// same way as GetGroup, as tags are equivalent to groups.
+func (ctn *Container) GetTagged(tag string) []interface{} {
+	return ctn.GetGroup(tag)
+}
+
+// getGroupInfo returns the services in the given group, sorted by their order,
+// registered in this container or, if there are none, the nearest parent
+// container with services in the group.
+func (ctn *Container) getGroupInfo(group string) []*Service {
+	svcs := make([]*Service, 0)
+
+	ctn.mu.RLock()
+	for _, s := range ctn.services {
+		if s.inGroup(group) {
+			svcs = append(svcs, s)
+		}
+	}
+	ctn.mu.RUnlock()
+	sortByOrder(svcs)
+
+	if len(svcs) == 0 && ctn.parent != nil {
+		return ctn.parent.getGroupInfo(group)
+	}
+
+	return svcs
+}
+
+// AddService adds a new service definition to the container. The ctor argument
+// should be the constructor function, which is used to build the service.
+//
+// A constructor function can contain an range of arguments, however, either
+// return an interface, or an interface and error: func() MyService or
+// func() (MyService, error).
+func (ctn *Container) AddService(ctor interface{}) *Service {
+	return ctn.addService(NewService(ctor))
+}
+
+// AddSingleton adds a new singleton service definition to the container,
+// in the same way as AddService.
+func (ctn *Container) AddSingleton(ctor interface{}) *Service {
+	return ctn.AddService(ctor).AsSingleton()
+}
+
+// AddTransient adds a new transient service definition to the container,
+// in the same way as AddService.
+func (ctn *Container) AddTransient(ctor interface{}) *Service {
+	return ctn.AddService(ctor).AsTransient()
+}
+
+// AddScoped adds a new scoped service definition to the container,
+// in the same way as AddService.
+func (ctn *Container) AddScoped(ctor interface{}) *Service {
+	return ctn.AddService(ctor).AsScoped()
+}
+
+// AddNamed adds a new service definition to the container, in the same way
+// as AddService, which can be resolved by each of the given names, such as
+// legacy names of the service. The first name is the service's name, and
+// the others are added as aliases, so each resolves the same service and
+// singleton instance. If names is empty, the service's name is derived from
+// its type.
+//
+// If the container is in strict mode, AddNamed panics if any of the names
+// are used by another service.
+func (ctn *Container) AddNamed(names []string, ctor interface{}) *Service {
+	s := NewService(ctor)
+	for i, name := range names {
+		switch {
+		case name == "" || name == s.name || slices.Contains(s.aliases, name):
+			continue
+		case i == 0:
+			s.name = name
+		default:
+			s.aliases = append(s.aliases, name)
+		}
+	}
+
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.add(s)
+	return s
+}
+
+// AddServiceIf adds a new service definition to the container, in the same way
+// as AddService, if cond is true. Otherwise, the Service returned is not added
+// to the container, so configuring it has no effect. The condition is only
+// evaluated once, when registering the service, not each time it's resolved.
+func (ctn *Container) AddServiceIf(cond bool, ctor interface{}) *Service {
+	if !cond {
+		return NewService(ctor)
+	}
+
+	return ctn.AddService(ctor)
+}
+
+// AddServiceFunc adds a new service definition to the container, in the same
+// way as AddServiceIf, where the condition is the result of calling cond.
+func (ctn *Container) AddServiceFunc(cond func() bool, ctor interface{}) *Service {
+	return ctn.AddServiceIf(cond(), ctor)
+}
+
+// GetOrAdd returns the service with the given name, if there is one. Otherwise,
+// a new service is added to the container, in the same way as AddService, with
+// the given name. This allows multiple modules to register a default service,
+// where the first to do so is used.
+//
+// If name is empty, the name of the service's type is used.
+func (ctn *Container) GetOrAdd(name string, ctor interface{}) *Service {
+	s := NewService(ctor)
+	if name != "" {
+		s.name = name
+	}
+
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	if svcs := ctn.byName[s.name]; len(svcs) > 0 {
+		return svcs[len(svcs)-1]
+	}
+
+	ctn.add(s)
+	return s
+}
+
+// addService is used to add an existing Service to the container.
+func (ctn *Container) addService(s *Service) *Service {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.add(s)
+	return s
+}
+
+// add is used to add the service s to the container. In strict mode,
+// this will panic if any of the names given to s, or the services it
+// outputs, are in use. The caller must hold the write lock.
+func (ctn *Container) add(s *Service) {
+	ctn.checkFrozen("add " + s.Name())
+	ctn.checkNames(s)
+
+	s.ctn = ctn
+	ctn.services = append(ctn.services, s)
+	ctn.index(s)
+
+	for _, o := range s.outputs {
+		ctn.add(o)
+	}
+}
+
+// index adds the service s to the type and name indexes.
+// The caller must hold the write lock.
+func (ctn *Container) index(s *Service) {
+	ctn.byName[s.name] = append(ctn.byName[s.name], s)
+	for _, a := range s.aliases {
+		ctn.byName[a] = append(ctn.byName[a], s)
+	}
+	ctn.byType[s.typ] = insertOrdered(ctn.byType[s.typ], s)
+	for _, t := range s.ifaces {
+		ctn.byType[t] = insertOrdered(ctn.byType[t], s)
+	}
+}
+
+// sortByOrder sorts svcs by their order, set using Service.WithOrder. The
+// sort is stable, so where svcs are in registration order, services with the
+// same order are kept in the order they were registered.
+func sortByOrder(svcs []*Service) {
+	slices.SortStableFunc(svcs, func(a, b *Service) int {
+		return cmp.Compare(a.order, b.order)
+	})
+}
+
+// insertOrdered inserts s into svcs, after the services with the same or a
+// lower order, so svcs is kept sorted by order, then registration order.
+// The services are copied, as svcs may be in use without the lock held.
+func insertOrdered(svcs []*Service, s *Service) []*Service {
+	svcs = append(slices.Clip(svcs), s)
+	sortByOrder(svcs)
+
+	return svcs
+}
+
+// reindex is used to rebuild the type and name indexes, after a
+// registered service's types or order have been changed.
+func (ctn *Container) reindex() {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("change the types or order of a service")
+	ctn.rebuildIndex()
+}
+
+// rename is used to set the name of the service s, then update the
+// indexes. In strict mode, this will panic if the name is in use.
+func (ctn *Container) rename(s *Service, name string) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("rename " + s.Name())
+	ctn.checkName(s, name)
+	s.name = name
+	ctn.rebuildIndex()
+}
+
+// alias is used to add an alias to the service s, then update the
+// indexes. In strict mode, this will panic if the name is in use.
+func (ctn *Container) alias(s *Service, name string) {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("alias " + s.Name())
+	ctn.checkName(s, name)
+	s.aliases = append(s.aliases, name)
+	ctn.rebuildIndex()
+}
+
+// checkName panics if the container is in strict mode and name is used
+// by a service other than s. The caller must hold the write lock.
+func (ctn *Container) checkName(s *Service, name string) {
+	if !ctn.strict {
+		return
+	}
+
+	for _, o := range ctn.byName[name] {
+		if o != s {
+			panic(fmt.Errorf("container: can not name %s %s, as the name is used by %s", s.typ.String(), name, o.typ.String()))
+		}
+	}
+}
+
+// checkNames panics if the container is in strict mode and any of the names
+// given to s, or the services it outputs, are used by another service. Names
+// derived from the service's type are not checked, as they're given by default.
+// The caller must hold the write lock.
+func (ctn *Container) checkNames(s *Service) {
+	if s.name != serviceName(s.typ) {
+		ctn.checkName(s, s.name)
+	}
+	for _, a := range s.aliases {
+		ctn.checkName(s, a)
+	}
+	for _, o := range s.outputs {
+		ctn.checkNames(o)
+	}
+}
+
+// rebuildIndex is used to rebuild the type and name indexes.
+// The caller must hold the write lock.
+func (ctn *Container) rebuildIndex() {
+	ctn.byType = make(map[reflect.Type][]*Service)
+	ctn.byName = make(map[string][]*Service)
+	for _, s := range ctn.services {
+		ctn.index(s)
+	}
+}
+
+// AddInstance adds an already built instance to the container as a
+// singleton service. The service's type is the type of instance, and
+// its name is derived in the same way as services added via AddService.
+//
+// This is useful for values constructed at startup, such as a *sql.DB,
+// removing the need to wrap them in a constructor function.
+func (ctn *Container) AddInstance(instance interface{}) *Service {
+	return ctn.addService(newInstance(instance))
+}
+
+// AddValue adds a constant value, such as a configuration struct or string,
+// to the container as a singleton service, in the same way as AddInstance.
+// The value is resolved directly, without calling a constructor.
+func (ctn *Container) AddValue(value interface{}) *Service {
+	return ctn.addService(newValue(value))
+}
+
+// AddNamedValue adds a constant value to the container, in the same way as
+// AddValue, with the given name. This is useful for values of common types,
+// such as a string, which would otherwise be named after their type.
+func (ctn *Container) AddNamedValue(name string, value interface{}) *Service {
+	s := newValue(value)
+	if name != "" {
+		s.name = name
+	}
+
+	return ctn.addService(s)
+}
+
+// newInstance returns a singleton service of the type of instance,
+// which has already been built. It panics if instance is nil.
+func newInstance(instance interface{}) *Service {
+	if instance == nil {
+		panic(fmt.Errorf("container: instance can not be nil"))
+	}
+
+	t := reflect.TypeOf(instance)
+	s := &Service{
+		name:     serviceName(t),
+		typ:      t,
+		lifetime: LifetimeSingleton,
+		ctor: reflect.MakeFunc(reflect.FuncOf(nil, []reflect.Type{t}, false), func([]reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.ValueOf(instance)}
+		}).Interface(),
+	}
+	s.setInstance(instance)
+
+	return s
+}
+
+// newValue returns a singleton service of the type of value, which resolves
+// value without a constructor. It panics if value is nil.
+func newValue(value interface{}) *Service {
+	if value == nil {
+		panic(fmt.Errorf("container: value can not be nil"))
+	}
+
+	t := reflect.TypeOf(value)
+	s := &Service{
+		name:     serviceName(t),
+		typ:      t,
+		lifetime: LifetimeSingleton,
+		value:    value,
+	}
+	s.sig.Store(&constructor{})
+	s.setInstance(value)
+
+	return s
+}
+
+// Decorate is used to wrap the service with the given name, using the decorator
+// constructor. The first parameter of decorator should be of the service's type,
+// and its return value must be assignable to it. Any other parameters are resolved
+// as dependencies.
+//
+// When the service is resolved, it is built then passed to the decorator, and
+// the decorated value is returned. Decorators are applied in the order they
+// were registered, and singletons are only decorated once.
+//
+// Only services registered in this container can be decorated, so a child
+// container can't change the services of its parent. Services which have
+// already been built, such as those added via AddInstance or AddValue, can't
+// be decorated either, as they won't be built again.
+func (ctn *Container) Decorate(name string, decorator interface{}) *Service {
+	ctn.mu.RLock()
+	svcs := ctn.byName[name]
+	ctn.mu.RUnlock()
+
+	if len(svcs) == 0 {
+		if ctn.parent != nil && ctn.parent.lookupName(name) != nil {
+			panic(fmt.Errorf("container: can not decorate %s, as it is registered in a parent container", name))
+		}
+
+		panic(ctn.notFound(name))
+	}
+
+	s := svcs[len(svcs)-1]
+
+	d := NewService(decorator)
+	t := reflect.TypeOf(decorator)
+	if t.NumIn() == 0 || !s.typ.AssignableTo(t.In(0)) {
+		panic(fmt.Errorf("container: decorator for %s should accept %s as its first parameter", name, s.typ.String()))
+	}
+	if !d.typ.AssignableTo(s.typ) {
+		panic(fmt.Errorf("container: decorator for %s should return a value assignable to %s", name, s.typ.String()))
+	}
+
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("decorate " + name)
+	if sg := s.inst.Load(); sg != nil && sg.built.Load() {
+		panic(fmt.Errorf("container: can not decorate %s, as it has already been built", name))
+	}
+
+	d.ctn = ctn
+	s.decorators = append(s.decorators, d)
+	return d
+}
+
+// Clean is used to clean up the services in the container. Once,
+// this func has been called, the container can still be used and services
+// built. However, this is intended to be called at the end of a program.
+//
+// If a service has a DisposeFunc, this will be called before it is removed
+// from the container. However, if there is no DisposeFunc, the service will
+// just be removed. Any errors returned by a DisposeFuncE are joined and returned,
+// once every service has been disposed.
+//
+// Services are disposed in reverse dependency order, so a service is disposed
+// before the services it depends on.
+func (ctn *Container) Clean(ctx context.Context) error {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	return ctn.dispose(ctx)
+}
+
+// dispose is used to dispose each of the container's services, in
+// reverse dependency order. The caller must hold the write lock.
+func (ctn *Container) dispose(ctx context.Context) error {
+	var errs []error
+	order := ctn.dependencyOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := order[i].Dispose(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Clear is used to remove every service from the container, after disposing
+// them in the same way as Clean. Unlike Clean, the container has no services
+// registered afterwards, so new services can be added, such as between tests.
+// The container's configuration, such as its context and hooks, is kept.
+//
+// Any errors returned by a DisposeFuncE are joined and returned, however,
+// the services are removed regardless. Clear panics if the container is frozen.
+func (ctn *Container) Clear(ctx context.Context) error {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("clear the container")
+
+	err := ctn.dispose(ctx)
+	for _, s := range ctn.services {
+		s.ctn = nil
+	}
+
+	ctn.services = make([]*Service, 0)
+	ctn.started = nil
+	ctn.rebuildIndex()
+
+	return err
+}
+
+// CleanParallel is used to dispose the container's services in the same
+// way as Clean, however, services which do not depend on one another are
+// disposed concurrently. Services are grouped into levels by their depth in
+// the dependency graph, where the services in a level are disposed at the
+// same time, and each level is only disposed once the one above it has been,
+// so a service is still disposed before the services it depends on.
+//
+// Any errors returned by a DisposeFuncE are joined and returned, once
+// every service has been disposed.
+func (ctn *Container) CleanParallel(ctx context.Context) error {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	levels := ctn.dependencyLevels()
+	for i := len(levels) - 1; i >= 0; i-- {
+		var wg sync.WaitGroup
+
+		for _, s := range levels[i] {
+			wg.Add(1)
+			go func(s *Service) {
+				defer wg.Done()
+
+				if err := s.Dispose(ctx); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}(s)
+		}
+
+		wg.Wait()
+	}
+
+	return errors.Join(errs...)
+}
+
+// ResolveWith is used to build a new instance of the service with the given
+// name, where overrides are given for some of its constructor's parameters.
+// Each parameter is given the first override assignable to its type, such as
+// a value only known when resolving the service, otherwise it is resolved from
+// the container as usual. Dependencies of other services are not overridden.
+//
+// A new instance is always built, even for a singleton service, and is not
+// cached, so the overrides do not affect later resolutions of the service.
+// Scoped services can't be resolved, as they can only be built in a Scope,
+// in which case ErrScopedOutsideScope is returned.
+func (ctn *Container) ResolveWith(name string, overrides ...interface{}) (interface{}, error) {
+	ctn.mu.RLock()
+	svcs := ctn.byName[name]
+	ctn.mu.RUnlock()
+
+	if len(svcs) == 0 {
+		return nil, ctn.notFound(name)
+	}
+
+	s := svcs[len(svcs)-1]
+	if s.lifetime == LifetimeScoped {
+		return nil, fmt.Errorf("%w, %s", ErrScopedOutsideScope, s.Name())
+	}
+
+	path, err := resolutionPath(nil).with(s)
+	if err != nil {
+		return nil, err
+	}
+	defer path.complete()
+
+	impl, err := s.construct(overrideResolver{
+		resolver:  ctn.resolver(ctn.ctx, path),
+		overrides: overrides,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w %s, %w", ErrBuildFailed, name, err)
+	}
+
+	return impl, nil
+}
+
+// overrideResolver is a resolver which resolves dependencies assignable
+// from one of overrides as the override, used by ResolveWith.
+type overrideResolver struct {
+	resolver
+	overrides []interface{}
+}
+
+func (r overrideResolver) Resolve(t reflect.Type) (interface{}, error) {
+	for _, o := range r.overrides {
+		if o != nil && reflect.TypeOf(o).AssignableTo(t) {
+			return o, nil
+		}
+	}
+
+	return r.resolver.Resolve(t)
+}
+
+// RebuildSingleton is used to replace the instance of the singleton service
+// with the given name, such as after its configuration has changed. A new
+// instance is built, then replaces the current one, which is then disposed
+// using the service's DisposeFunc. Concurrent resolutions of the service
+// return the current instance, until it has been replaced, without waiting
+// for the new instance to be built. Where the service is rebuilt concurrently,
+// the last instance built is kept, and each instance replaced is disposed.
+//
+// If the new instance fails to build, the current instance is kept and
+// the error is returned.
+func (ctn *Container) RebuildSingleton(name string, ctx context.Context) (interface{}, error) {
+	ctn.mu.RLock()
+	svcs := ctn.byName[name]
+	ctn.mu.RUnlock()
+
+	if len(svcs) == 0 {
+		return nil, ctn.notFound(name)
+	}
+
+	s := svcs[len(svcs)-1]
+	if s.currentLifetime() != LifetimeSingleton {
+		return nil, fmt.Errorf("container: can not rebuild %s, as it is not a singleton", name)
+	}
+
+	path, err := resolutionPath(nil).with(s)
+	if err != nil {
+		return nil, err
+	}
+	defer path.complete()
+
+	impl, err := s.traced(LifetimeSingleton, 0, ctn.resolver(ctn.ctx, path))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s, %w", ErrBuildFailed, name, err)
+	}
+
+	s.mu.Lock()
+	old := s.inst.Swap(newSingleton(impl))
+	s.mu.Unlock()
+
+	if old != nil && old.built.Load() && !old.borrowed {
+		if err := s.disposeInstance(ctx, old.impl); err != nil {
+			return impl, err
+		}
+	}
+
+	return impl, nil
+}
+
+// CleanTimeout is used to clean up the services in the container, in the same
+// way as Clean, but each service's DisposeFunc is given at most perService to
+// return. Where a DisposeFunc takes longer, it is abandoned and left to return
+// in the background, and an error wrapping ErrDisposeTimeout is returned for
+// the service. The context.Context given to each DisposeFunc is cancelled once
+// its time has elapsed.
+//
+// The service's instance is removed before its DisposeFunc is called, so an
+// abandoned DisposeFunc does not affect services built after CleanTimeout.
+func (ctn *Container) CleanTimeout(ctx context.Context, perService time.Duration) error {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	var errs []error
+	order := ctn.dependencyOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		s := order[i]
+		impl, ok := s.release()
+		if !ok {
+			continue
+		}
+
+		if err := s.disposeTimeout(ctx, impl, perService); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// VerifyAllResolvable attempts to build every service in the container, returning
+// an error listing each service which failed. This is intended to be used as a
+// smoke test when an application starts.
+//
+// Services are built using ctx. Singleton services will be built and cached as
+// usual, whereas scoped and transient services are built within a temporary
+// Scope, so transients can depend on scoped services. The Scope is then
+// disposed, and any errors disposing it are returned with the others.
+func (ctn *Container) VerifyAllResolvable(ctx context.Context) error {
+	ctn.mu.RLock()
+	svcs := make([]*Service, len(ctn.services))
+	copy(svcs, ctn.services)
+	ctn.mu.RUnlock()
+
+	scope := ctn.CreateScopeWithContext(ctx)
+
+	var errs []error
+	for _, s := range svcs {
+		var err error
+		if s.lifetime == LifetimeScoped || s.isTransient() {
+			scope.mu.Lock()
+			_, err = scope.get(ctx, nil, s)
+			scope.mu.Unlock()
+		} else {
+			_, err = ctn.build(ctx, nil, s)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := scope.Dispose(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// WarmUp is used to build every singleton service in the container, so they
+// are not built when first resolved, and so any which fail to build do so
+// when an application starts. Singletons are built concurrently, where
+// they do not depend on one another, in order of their dependencies.
+//
+// The errors of any singletons which failed to build are joined and returned.
+// Singletons are built with ctx, so constructors can observe its cancellation,
+// and if ctx is done before all singletons are built, no more are built and
+// its error is returned.
+func (ctn *Container) WarmUp(ctx context.Context) error {
+	ctn.mu.RLock()
+	levels := ctn.dependencyLevels()
+	ctn.mu.RUnlock()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, level := range levels {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+
+		for _, s := range level {
+			if s.lifetime != LifetimeSingleton {
+				continue
+			}
+
+			wg.Add(1)
+			go func(s *Service) {
+				defer wg.Done()
+
+				if _, err := ctn.build(ctx, nil, s); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}(s)
+		}
+
+		wg.Wait()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+func (ctn *Container) getServiceInfo(name string) *Service {
+	if s := ctn.lookupName(name); s != nil {
+		return s
+	}
+
+	panic(ctn.notFound(name))
+}
+
+// lookupName returns the service with the given name, or nil if there
+// isn't one, in this container or its parent.
+func (ctn *Container) lookupName(name string) *Service {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	if svcs := ctn.byName[name]; len(svcs) > 0 {
+		return svcs[len(svcs)-1]
+	}
+
+	if ctn.parent != nil {
+		return ctn.parent.lookupName(name)
+	}
+
+	return nil
+}
+
+// getServiceInfoByType returns the service which can be resolved as the
+// type t, or nil if there isn't one. If there are multiple, an error is returned.
+func (ctn *Container) getServiceInfoByType(t reflect.Type) (*Service, error) {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	s, err := ctn.lookup(t)
+	if s == nil && err == nil && ctn.parent != nil {
+		return ctn.parent.getServiceInfoByType(t)
+	}
+
+	return s, err
+}
+
+// lookup returns the service which can be resolved as the type t, or nil if
+// there isn't one. If there are multiple, the primary service is returned,
+// otherwise an error is returned listing them. The caller must hold the
+// read lock.
+func (ctn *Container) lookup(t reflect.Type) (*Service, error) {
+	svcs := ctn.byType[t]
+	switch len(svcs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return svcs[0], nil
+	default:
+		if p := primaryOf(svcs); p != nil {
+			return p, nil
+		}
+
+		names := make([]string, len(svcs))
+		for i, s := range svcs {
+			names[i] = s.Name()
+		}
+
+		return nil, fmt.Errorf("%w: %s matches %s", ErrAmbiguousDependency, t.String(), strings.Join(names, ", "))
+	}
+}
+
+// primaryOf returns the primary service of svcs, or nil if
+// there is not exactly one.
+func primaryOf(svcs []*Service) *Service {
+	var primary *Service
+	for _, s := range svcs {
+		if !s.primary {
+			continue
+		}
+		if primary != nil {
+			return nil
+		}
+		primary = s
+	}
+
+	return primary
+}
+
+// CreateChild is used to create a child container, which resolves its own
+// services first, then falls back to this container. Services registered
+// in the child shadow those in this container, by name and type, without
+// affecting it.
+//
+// Services resolved from this container, through the child, are built
+// using this container's services, so singletons remain shared.
+func (ctn *Container) CreateChild() *Container {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	child := NewContainer(WithContext(ctn.ctx), WithLogger(ctn.logger))
+	child.noRecover = ctn.noRecover
+	child.strict = ctn.strict
+	child.onBuild = ctn.onBuild
+	child.onResolve = ctn.onResolve
+	child.maxDepth = ctn.maxDepth
+	child.parent = ctn
+	return child
+}
+
+// Clone is used to create an independent copy of the container, with the
+// same configuration and services, such as to isolate tests running in
+// parallel. Singletons are not copied, so each container builds its own, and
+// services registered in either container afterwards don't affect the other.
+//
+// The clone is not frozen, even if this container is, and has the same
+// parent container, if any.
+func (ctn *Container) Clone() *Container {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	clone := NewContainer(WithContext(ctn.ctx), WithLogger(ctn.logger))
+	clone.noRecover = ctn.noRecover
+	clone.strict = ctn.strict
+	clone.onBuild = ctn.onBuild
+	clone.onResolve = ctn.onResolve
+	clone.maxDepth = ctn.maxDepth
+	clone.parent = ctn.parent
+	clone.middleware = append(clone.middleware, ctn.middleware...)
+
+	for _, s := range ctn.services {
+		c := s.clone()
+		c.ctn = clone
+		clone.services = append(clone.services, c)
+	}
+	clone.rebuildIndex()
+
+	return clone
+}
+
+// CreateScope is used to create a scoped service provider, with the
+// Container's base context.Context configured.
+func (ctn *Container) CreateScope() *Scope {
+	return ctn.CreateScopeWithContext(ctn.ctx)
+}
+
+// CreateScopeWithContext is used to create a scope service provider,
+// with the given context.Context configured.
+func (ctn *Container) CreateScopeWithContext(ctx context.Context, opts ...ScopeOption) *Scope {
+	return newScope(ctn, ctx, opts...)
+}
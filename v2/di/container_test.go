@@ -1,148 +1,1907 @@
-package di
-
-import (
-	"context"
-	"reflect"
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-)
-
-func TestContainer_GetService(t *testing.T) {
-	t.Run("Where Service Exists", func(t *testing.T) {
-		ctor1 := func() *testDependency {
-			return &testDependency{}
-		}
-		ctor2 := func() *testDependency2 {
-			return &testDependency2{}
-		}
-
-		ctn := NewContainer()
-		ctn.AddService(ctor1)
-		ctn.AddService(ctor2).SetName("MyService")
-
-		v, ok := ctn.GetService("MyService").(*testDependency2)
-		assert.NotNil(t, v)
-		assert.True(t, ok)
-	})
-
-	t.Run("Where Build Fails", func(t *testing.T) {
-		ctor := func() (*testDependency, error) {
-			return nil, assert.AnError
-		}
-
-		ctn := NewContainer()
-		ctn.AddService(ctor).SetName("MyService")
-
-		defer func() {
-			err := recover().(error)
-			assert.NotNil(t, err)
-			assert.Contains(t, err.Error(), assert.AnError.Error())
-		}()
-
-		// Should panic
-		_ = ctn.GetService("MyService")
-	})
-
-	t.Run("Where The Service Does Not Exist", func(t *testing.T) {
-		ctn := NewContainer()
-
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("Expected panic")
-			}
-		}()
-
-		_ = ctn.GetService("MyService")
-	})
-}
-
-func TestContainer_GetServices(t *testing.T) {
-	t.Run("Where Services Exists", func(t *testing.T) {
-		srv1 := &testDependency{}
-		srv2 := &testDependency{}
-		ctor1 := func() *testDependency {
-			return srv1
-		}
-		ctor2 := func() *testDependency {
-			return srv2
-		}
-		ctor3 := func() *testDependency2 {
-			return &testDependency2{}
-		}
-
-		ctn := NewContainer()
-		ctn.AddService(ctor1)
-		ctn.AddService(ctor2)
-		ctn.AddService(ctor3)
-
-		arr := ctn.GetServices(reflect.TypeOf(&testDependency{}))
-		assert.ElementsMatch(t, arr, []interface{}{srv1, srv2})
-	})
-
-	t.Run("Where Build Fails", func(t *testing.T) {
-		ctor := func() (*testDependency, error) {
-			return nil, assert.AnError
-		}
-
-		ctn := NewContainer()
-		ctn.AddService(ctor).SetName("MyService")
-
-		defer func() {
-			err := recover().(error)
-			assert.NotNil(t, err)
-			assert.Contains(t, err.Error(), assert.AnError.Error())
-		}()
-
-		// Should panic
-		_ = ctn.GetServices(reflect.TypeOf(&testDependency{}))
-	})
-
-	t.Run("Where No Services Exist", func(t *testing.T) {
-		ctn := NewContainer()
-
-		arr := ctn.GetServices(reflect.TypeOf(&testDependency{}))
-		assert.Len(t, arr, 0)
-	})
-}
-
-func TestContainer_AddService(t *testing.T) {
-	ctor := func() interface{} {
-		return nil
-	}
-
-	ctn := NewContainer()
-	s := ctn.AddService(ctor)
-	assert.NotNil(t, s)
-	assert.Same(t, s, ctn.services[0])
-}
-
-func TestContainer_Clean(t *testing.T) {
-	hasBeenDisposed := false
-	testCtx := context.Background()
-	testValue := "My String"
-
-	ctn := NewContainer()
-	ctn.AddService(func() interface{} {
-		return testValue
-	}).
-		AsSingleton().
-		SetDispose(func(ctx context.Context, i interface{}) {
-			assert.Equal(t, testCtx, ctx)
-			assert.Equal(t, testValue, i)
-
-			// Proves that the dispose has only been called once.
-			assert.False(t, hasBeenDisposed)
-
-			hasBeenDisposed = true
-		}).
-		SetName("MyService")
-
-	// Builds the service
-	_ = ctn.GetService("MyService")
-
-	ctn.Clean(testCtx)
-
-	assert.True(t, hasBeenDisposed)
-	assert.Nil(t, ctn.services[0].impl)
-}
+package di
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_GetService(t *testing.T) {
+	t.Run("Where Service Exists", func(t *testing.T) {
+		ctor1 := func() *testDependency {
+			return &testDependency{}
+		}
+		ctor2 := func() *testDependency2 {
+			return &testDependency2{}
+		}
+
+		ctn := NewContainer()
+		ctn.AddService(ctor1)
+		ctn.AddService(ctor2).SetName("MyService")
+
+		v, ok := ctn.GetService("MyService").(*testDependency2)
+		assert.NotNil(t, v)
+		assert.True(t, ok)
+	})
+
+	t.Run("Where Build Fails", func(t *testing.T) {
+		ctor := func() (*testDependency, error) {
+			return nil, assert.AnError
+		}
+
+		ctn := NewContainer()
+		ctn.AddService(ctor).SetName("MyService")
+
+		defer func() {
+			err := recover().(error)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), assert.AnError.Error())
+		}()
+
+		// Should panic
+		_ = ctn.GetService("MyService")
+	})
+
+	t.Run("Where The Service Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected panic")
+			}
+		}()
+
+		_ = ctn.GetService("MyService")
+	})
+
+	t.Run("Where Service Is Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddService(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		})
+
+		_, err := ctn.TryGetService("di.testDependency")
+		assert.ErrorIs(t, err, ErrScopedOutsideScope)
+		assert.Contains(t, err.Error(), "di.testDependency")
+
+		// Also when the scoped service is a dependency.
+		_, err = ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, ErrScopedOutsideScope)
+
+		// Both can be resolved from a Scope.
+		scope := ctn.CreateScope()
+		d := scope.GetService("di.testDependency")
+		v := scope.GetService("di.testService").(*testService)
+		assert.Same(t, d, v.dep)
+	})
+}
+
+type testServiceFactory struct {
+	sp ServiceProvider
+}
+
+func TestContainer_ServiceProviderDependency(t *testing.T) {
+	t.Run("Given Container", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddService(func(sp ServiceProvider, c *Container) *testService {
+			assert.Same(t, ctn, c)
+			return &testService{dep: sp.GetService("di.testDependency").(*testDependency)}
+		})
+
+		v := GetService[*testService](ctn)
+		assert.Same(t, ctn.GetService("di.testDependency"), v.dep)
+	})
+
+	t.Run("Given Scope", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddScoped(func(sp ServiceProvider) *testServiceFactory {
+			return &testServiceFactory{sp: sp}
+		})
+
+		scope := ctn.CreateScope()
+		f := GetService[*testServiceFactory](scope)
+		assert.Same(t, scope, f.sp)
+		assert.Same(t, scope.GetService("di.testDependency"), f.sp.GetService("di.testDependency"))
+	})
+
+	t.Run("Where Provider Is Registered", func(t *testing.T) {
+		other := NewContainer()
+
+		ctn := NewContainer()
+		ctn.AddInstance(other).As((*ServiceProvider)(nil))
+		ctn.AddService(func(sp ServiceProvider) *testServiceFactory {
+			return &testServiceFactory{sp: sp}
+		})
+
+		assert.Same(t, other, GetService[*testServiceFactory](ctn).sp)
+	})
+}
+
+func TestContainer_GetServiceByType(t *testing.T) {
+	t.Run("Given Pointer Type", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func() *testService {
+			return &testService{x: 1}
+		})
+
+		v, err := ctn.GetServiceByType(reflect.TypeOf(&testService{}))
+		assert.NoError(t, err)
+		assert.Same(t, ctn.GetService("di.testService"), v)
+	})
+
+	t.Run("Given Interface Type", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testSQLRepository {
+			return &testSQLRepository{}
+		})
+
+		v, err := ctn.GetServiceByType(reflect.TypeOf((*testRepository)(nil)).Elem())
+		assert.NoError(t, err)
+		assert.IsType(t, &testSQLRepository{}, v)
+	})
+
+	t.Run("Where Multiple Services Are Assignable", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testSQLRepository {
+			return &testSQLRepository{}
+		})
+		ctn.AddService(func() *testMemoryRepository {
+			return &testMemoryRepository{}
+		})
+
+		_, err := ctn.GetServiceByType(reflect.TypeOf((*testRepository)(nil)).Elem())
+		assert.ErrorIs(t, err, ErrAmbiguousDependency)
+	})
+
+	t.Run("Where No Service Is Assignable", func(t *testing.T) {
+		ctn := NewContainer()
+
+		_, err := ctn.GetServiceByType(reflect.TypeOf((*testRepository)(nil)).Elem())
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+	})
+}
+
+func TestContainer_GetService_SliceDependency(t *testing.T) {
+	t.Run("Where Services Exist", func(t *testing.T) {
+		ctn := NewContainer()
+		Register[testMiddleware](ctn, func() testStage { return "first" })
+		Register[testMiddleware](ctn, func() testStage { return "second" })
+		ctn.AddService(func(mw []testMiddleware) *testService {
+			return &testService{x: len(mw)}
+		}).SetName("MyService")
+
+		v := ctn.GetService("MyService").(*testService)
+		assert.Equal(t, 2, v.x)
+	})
+
+	t.Run("Where No Services Exist", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(mw []testMiddleware) *testService {
+			assert.NotNil(t, mw)
+			return &testService{x: len(mw)}
+		}).SetName("MyService")
+
+		v := ctn.GetService("MyService").(*testService)
+		assert.Equal(t, 0, v.x)
+	})
+
+	t.Run("Where Slice Type Is Registered", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() []testMiddleware {
+			return []testMiddleware{testStage("a"), testStage("b"), testStage("c")}
+		})
+		Register[testMiddleware](ctn, func() testStage { return "first" })
+		ctn.AddService(func(mw []testMiddleware) *testService {
+			return &testService{x: len(mw)}
+		}).SetName("MyService")
+
+		v := ctn.GetService("MyService").(*testService)
+		assert.Equal(t, 3, v.x)
+	})
+}
+
+func TestContainer_TryGetService(t *testing.T) {
+	t.Run("Where Service Exists", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			return &testService{}
+		})
+
+		v, err := ctn.TryGetService("di.testService")
+		assert.NoError(t, err)
+		assert.IsType(t, &testService{}, v)
+	})
+
+	t.Run("Where Service Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		v, err := ctn.TryGetService("di.testService")
+		assert.Nil(t, v)
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.NotErrorIs(t, err, ErrBuildFailed)
+		assert.EqualError(t, err, "container: could not find service, di.testService")
+	})
+
+	t.Run("Where Name Is Similar To A Registered Name", func(t *testing.T) {
+		ctn := NewContainer()
+		for i := 0; i < 10; i++ {
+			ctn.AddInstance(&testService{}).SetName(fmt.Sprintf("unrelated%d", i))
+		}
+		ctn.AddInstance(&testService{}).SetName("userRepository")
+
+		_, err := ctn.CreateChild().TryGetService("userRepsitory")
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.EqualError(t, err, "container: could not find service, userRepsitory, did you mean userRepository?")
+	})
+
+	t.Run("Where Few Services Are Registered", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddInstance(&testService{}).SetName("users")
+		ctn.AddInstance(&testService{}).SetName("orders")
+
+		_, err := ctn.TryGetService("payments")
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.EqualError(t, err, "container: could not find service, payments, registered services are orders, users")
+	})
+
+	t.Run("Where Build Fails", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() (*testService, error) {
+			return nil, assert.AnError
+		})
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, ErrBuildFailed)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NotErrorIs(t, err, ErrServiceNotFound)
+	})
+
+	t.Run("Where Dependency Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(d *testDependency) *testService {
+			return &testService{}
+		})
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, ErrBuildFailed)
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+	})
+
+	t.Run("Where Services Depend On Each Other", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(b *testCycleB) *testCycleA {
+			return &testCycleA{}
+		})
+		ctn.AddService(func(a *testCycleA) *testCycleB {
+			return &testCycleB{}
+		})
+
+		_, err := ctn.TryGetService("di.testCycleA")
+		assert.ErrorIs(t, err, ErrBuildFailed)
+		assert.ErrorIs(t, err, ErrCircularDependency)
+	})
+}
+
+func TestContainer_Use(t *testing.T) {
+	stub := &testService{x: 1}
+	order := make([]string, 0)
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testService {
+		return &testService{}
+	})
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.Use(func(next func(name string) interface{}) func(name string) interface{} {
+		return func(name string) interface{} {
+			order = append(order, "first")
+			if name == "di.testService" {
+				return stub
+			}
+			return next(name)
+		}
+	})
+	ctn.Use(func(next func(name string) interface{}) func(name string) interface{} {
+		return func(name string) interface{} {
+			order = append(order, "second")
+			return next(name)
+		}
+	})
+
+	// The first middleware should substitute the service,
+	// without calling the next middleware.
+	assert.Same(t, stub, ctn.GetService("di.testService"))
+	assert.Equal(t, []string{"first"}, order)
+
+	order = order[:0]
+	assert.IsType(t, &testDependency{}, ctn.GetService("di.testDependency"))
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestContainer_TryGetServices(t *testing.T) {
+	t.Run("Where One Service Fails To Build", func(t *testing.T) {
+		ctn := NewContainer()
+		Register[testMiddleware](ctn, func() testStage { return "first" })
+		Register[testMiddleware](ctn, func() (testStage, error) {
+			return "", assert.AnError
+		}).SetName("broken")
+		Register[testMiddleware](ctn, func() testStage { return "third" })
+
+		vs, err := ctn.TryGetServices(reflect.TypeOf((*testMiddleware)(nil)).Elem())
+		assert.Equal(t, []interface{}{testStage("first"), testStage("third")}, vs)
+		assert.ErrorIs(t, err, ErrBuildFailed)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "broken")
+	})
+
+	t.Run("Where All Services Build", func(t *testing.T) {
+		ctn := NewContainer()
+		Register[testMiddleware](ctn, func() testStage { return "first" })
+
+		vs, err := ctn.TryGetServices(reflect.TypeOf((*testMiddleware)(nil)).Elem())
+		assert.NoError(t, err)
+		assert.Len(t, vs, 1)
+	})
+}
+
+func TestContainer_GetServices(t *testing.T) {
+	t.Run("Where Services Exists", func(t *testing.T) {
+		srv1 := &testDependency{}
+		srv2 := &testDependency{}
+		ctor1 := func() *testDependency {
+			return srv1
+		}
+		ctor2 := func() *testDependency {
+			return srv2
+		}
+		ctor3 := func() *testDependency2 {
+			return &testDependency2{}
+		}
+
+		ctn := NewContainer()
+		ctn.AddService(ctor1)
+		ctn.AddService(ctor2)
+		ctn.AddService(ctor3)
+
+		arr := ctn.GetServices(reflect.TypeOf(&testDependency{}))
+		assert.ElementsMatch(t, arr, []interface{}{srv1, srv2})
+	})
+
+	t.Run("Where Build Fails", func(t *testing.T) {
+		ctor := func() (*testDependency, error) {
+			return nil, assert.AnError
+		}
+
+		ctn := NewContainer()
+		ctn.AddService(ctor).SetName("MyService")
+
+		defer func() {
+			err := recover().(error)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), assert.AnError.Error())
+		}()
+
+		// Should panic
+		_ = ctn.GetServices(reflect.TypeOf(&testDependency{}))
+	})
+
+	t.Run("Given Registration Order", func(t *testing.T) {
+		ctn := NewContainer()
+		for _, name := range []string{"c", "a", "d", "b"} {
+			ctn.AddInstance(testStage(name)).As((*testMiddleware)(nil))
+		}
+
+		names := make([]string, 0)
+		for _, m := range GetServices[testMiddleware](ctn) {
+			names = append(names, m.Name())
+		}
+		assert.Equal(t, []string{"c", "a", "d", "b"}, names)
+	})
+
+	t.Run("Given Explicit Order", func(t *testing.T) {
+		ctn := NewContainer()
+		Register[testMiddleware](ctn, func() testStage { return "first" }).WithOrder(1)
+		Register[testMiddleware](ctn, func() testStage { return "second" })
+		Register[testMiddleware](ctn, func() testStage { return "third" }).WithOrder(-1)
+		Register[testMiddleware](ctn, func() testStage { return "fourth" }).WithOrder(1)
+		Register[testMiddleware](ctn, func() testStage { return "fifth" })
+
+		names := make([]string, 0)
+		for _, m := range GetServices[testMiddleware](ctn) {
+			names = append(names, m.Name())
+		}
+		assert.Equal(t, []string{"third", "second", "fifth", "first", "fourth"}, names)
+
+		// Slice dependencies should be ordered in the same way.
+		var injected []string
+		ctn.AddService(func(mw []testMiddleware) *testService {
+			for _, m := range mw {
+				injected = append(injected, m.Name())
+			}
+			return &testService{}
+		})
+		_ = GetService[*testService](ctn)
+		assert.Equal(t, names, injected)
+	})
+
+	t.Run("Where No Services Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		arr := ctn.GetServices(reflect.TypeOf(&testDependency{}))
+		assert.Len(t, arr, 0)
+	})
+
+	t.Run("Where Services Are In Parent", func(t *testing.T) {
+		parent := NewContainer()
+		parent.AddInstance(testStage("first")).As((*testMiddleware)(nil))
+		parent.AddInstance(testStage("second")).As((*testMiddleware)(nil))
+		child := parent.CreateChild()
+
+		// Explicit lookups should match slice injection.
+		var injected []testMiddleware
+		child.AddService(func(mw []testMiddleware) *testService {
+			injected = mw
+			return &testService{}
+		})
+		_ = GetService[*testService](child)
+
+		assert.Len(t, injected, 2)
+		assert.Len(t, GetServices[testMiddleware](child), 2)
+		assert.Len(t, GetServices[testMiddleware](child.CreateScope()), 2)
+
+		vs, err := child.TryGetServices(reflect.TypeOf((*testMiddleware)(nil)).Elem())
+		assert.NoError(t, err)
+		assert.Len(t, vs, 2)
+		assert.Len(t, child.GetServicesAssignable(reflect.TypeOf((*testMiddleware)(nil)).Elem()), 2)
+	})
+}
+
+func TestContainer_GetGroup(t *testing.T) {
+	t.Run("Where Services Exist", func(t *testing.T) {
+		srv1 := &testDependency{}
+		srv2 := &testDependency2{}
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return srv1 }).InGroup("MyGroup")
+		ctn.AddService(func() *testService { return &testService{} })
+		ctn.AddService(func() *testDependency2 { return srv2 }).InGroup("MyGroup")
+
+		arr := ctn.GetGroup("MyGroup")
+		assert.Equal(t, []interface{}{srv1, srv2}, arr)
+	})
+
+	t.Run("Where No Services Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		arr := ctn.GetGroup("MyGroup")
+		assert.Len(t, arr, 0)
+	})
+
+	t.Run("Where Services Are In Parent", func(t *testing.T) {
+		srv := &testDependency{}
+
+		parent := NewContainer()
+		parent.AddService(func() *testDependency { return srv }).InGroup("MyGroup")
+		child := parent.CreateChild()
+
+		assert.Equal(t, []interface{}{srv}, child.GetGroup("MyGroup"))
+		assert.Equal(t, []interface{}{srv}, child.CreateScope().GetGroup("MyGroup"))
+	})
+}
+
+func TestContainer_GetTagged(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).WithTag("plugin")
+	ctn.AddService(func() *testDependency2 {
+		return &testDependency2{}
+	})
+	ctn.AddService(func() *testService {
+		return &testService{}
+	}).WithTag("other", "plugin")
+
+	v := ctn.GetTagged("plugin")
+	assert.Len(t, v, 2)
+	assert.IsType(t, &testDependency{}, v[0])
+	assert.IsType(t, &testService{}, v[1])
+
+	assert.Len(t, ctn.GetTagged("missing"), 0)
+}
+
+func TestContainer_GetServicesAssignable(t *testing.T) {
+	repoType := reflect.TypeOf((*testRepository)(nil)).Elem()
+
+	t.Run("Where Services Implement Interface", func(t *testing.T) {
+		repo1 := &testSQLRepository{}
+		repo2 := testStage("stage")
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testSQLRepository {
+			return repo1
+		})
+		ctn.AddService(func() testStage {
+			return repo2
+		})
+		ctn.AddService(func() testRepository {
+			return repo1
+		})
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		})
+
+		// Only services registered as the interface are found by GetServices.
+		assert.Len(t, ctn.GetServices(repoType), 1)
+
+		arr := ctn.GetServicesAssignable(repoType)
+		assert.Equal(t, []interface{}{repo1, repo1}, arr)
+
+		arr = ctn.GetServicesAssignable(reflect.TypeOf((*testMiddleware)(nil)).Elem())
+		assert.Equal(t, []interface{}{repo2}, arr)
+	})
+
+	t.Run("Where Build Fails", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() (*testSQLRepository, error) {
+			return nil, assert.AnError
+		})
+
+		assert.Panics(t, func() {
+			_ = ctn.GetServicesAssignable(repoType)
+		})
+	})
+
+	t.Run("Where No Services Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		arr := ctn.GetServicesAssignable(repoType)
+		assert.Len(t, arr, 0)
+	})
+}
+
+func TestContainer_AsPrimary(t *testing.T) {
+	repoType := reflect.TypeOf((*testRepository)(nil)).Elem()
+
+	t.Run("Where One Service Is Primary", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		})
+		ctn.AddService(func() testRepository {
+			return &testMemoryRepository{}
+		}).AsPrimary()
+		ctn.AddService(func(r testRepository) *testService {
+			assert.Equal(t, "memory", r.Get())
+			return &testService{}
+		})
+
+		v, err := ctn.Resolve(repoType)
+		assert.NoError(t, err)
+		assert.IsType(t, &testMemoryRepository{}, v)
+		assert.NotNil(t, ctn.GetService("di.testService"))
+		assert.NoError(t, ctn.Validate())
+
+		// All services should still be retrieved.
+		assert.Len(t, ctn.GetServices(repoType), 2)
+	})
+
+	t.Run("Where No Service Is Primary", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		})
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		})
+
+		_, err := ctn.Resolve(repoType)
+		assert.ErrorIs(t, err, ErrAmbiguousDependency)
+	})
+
+	t.Run("Where Multiple Services Are Primary", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		}).AsPrimary()
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		}).AsPrimary()
+
+		_, err := ctn.Resolve(repoType)
+		assert.ErrorIs(t, err, ErrAmbiguousDependency)
+	})
+}
+
+func TestContainer_AddService(t *testing.T) {
+	ctor := func() interface{} {
+		return nil
+	}
+
+	ctn := NewContainer()
+	s := ctn.AddService(ctor)
+	assert.NotNil(t, s)
+	assert.Same(t, s, ctn.services[0])
+}
+
+func TestContainer_AddServiceIf(t *testing.T) {
+	ctor := func() *testService {
+		return &testService{}
+	}
+
+	t.Run("Where Condition Is True", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddServiceIf(true, ctor).SetName("MyService")
+		assert.Equal(t, []*Service{s}, ctn.services)
+		assert.NotNil(t, ctn.GetService("MyService"))
+	})
+
+	t.Run("Where Condition Is False", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddServiceIf(false, ctor).SetName("MyService").AsSingleton()
+		assert.NotNil(t, s)
+		assert.Empty(t, ctn.services)
+		assert.Panics(t, func() {
+			_ = ctn.GetService("MyService")
+		})
+	})
+
+	t.Run("Given Condition Func", func(t *testing.T) {
+		calls := 0
+		cond := func() bool {
+			calls++
+			return true
+		}
+
+		ctn := NewContainer()
+		ctn.AddServiceFunc(cond, ctor)
+		_ = ctn.GetService("di.testService")
+		_ = ctn.GetService("di.testService")
+
+		// The condition should only be evaluated at registration.
+		assert.Equal(t, 1, calls)
+		assert.Len(t, ctn.services, 1)
+	})
+}
+
+func TestContainer_GetOrAdd(t *testing.T) {
+	t.Run("Where Service Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.GetOrAdd("logger", func() *testDependency {
+			return &testDependency{}
+		})
+
+		assert.Equal(t, "logger", s.Name())
+		assert.Equal(t, []*Service{s}, ctn.services)
+	})
+
+	t.Run("Where Service Exists", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		}).SetName("logger")
+
+		v := ctn.GetOrAdd("logger", func() *testDependency2 {
+			return &testDependency2{}
+		})
+
+		assert.Same(t, s, v)
+		assert.Len(t, ctn.services, 1)
+	})
+
+	t.Run("Where Called Concurrently", func(t *testing.T) {
+		ctn := NewContainer()
+
+		var wg sync.WaitGroup
+		svcs := make([]*Service, 50)
+		for i := range svcs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				svcs[i] = ctn.GetOrAdd("logger", func() *testDependency {
+					return &testDependency{}
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Len(t, ctn.services, 1)
+		for _, s := range svcs {
+			assert.Same(t, ctn.services[0], s)
+		}
+	})
+}
+
+func TestContainer_Freeze(t *testing.T) {
+	ctn := NewContainer()
+	s := ctn.AddService(func() *testService {
+		return &testService{}
+	}).AsSingleton()
+	assert.Same(t, ctn, ctn.Freeze())
+
+	assert.PanicsWithError(t, "container: can not add di.testDependency, as the container is frozen", func() {
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		})
+	})
+	assert.Panics(t, func() {
+		ctn.AddInstance(&testDependency{})
+	})
+	assert.Panics(t, func() {
+		ctn.GetOrAdd("MyService", func() *testDependency {
+			return &testDependency{}
+		})
+	})
+	assert.Panics(t, func() {
+		s.SetName("MyService")
+	})
+	assert.Panics(t, func() {
+		ctn.Decorate("di.testService", func(s *testService) *testService {
+			return s
+		})
+	})
+	assert.Len(t, ctn.services, 1)
+
+	// Existing services should still be resolved.
+	assert.Same(t, s, ctn.GetOrAdd("di.testService", func() *testService {
+		return &testService{}
+	}))
+	assert.NotNil(t, ctn.GetService("di.testService"))
+}
+
+func TestContainer_AddLifetime(t *testing.T) {
+	ctor := func() *testService {
+		return &testService{}
+	}
+
+	ctn := NewContainer()
+	singleton := ctn.AddSingleton(ctor).SetName("Singleton")
+	transient := ctn.AddTransient(ctor).SetName("Transient")
+	scoped := ctn.AddScoped(ctor).SetName("Scoped")
+
+	assert.Equal(t, []*Service{singleton, transient, scoped}, ctn.services)
+	assert.Equal(t, LifetimeSingleton, singleton.lifetime)
+	assert.Equal(t, LifetimeTransient, transient.lifetime)
+	assert.Equal(t, LifetimeScoped, scoped.lifetime)
+	assert.Same(t, ctn.GetService("Singleton"), ctn.GetService("Singleton"))
+	assert.NotSame(t, ctn.GetService("Transient"), ctn.GetService("Transient"))
+}
+
+func TestContainer_Index(t *testing.T) {
+	ctn := NewContainer()
+	s := ctn.AddService(func() *testSQLRepository {
+		return &testSQLRepository{}
+	})
+	assert.Equal(t, []*Service{s}, ctn.byName["di.testSQLRepository"])
+	assert.Equal(t, []*Service{s}, ctn.byType[reflect.TypeOf(&testSQLRepository{})])
+
+	// Changing the name and types should update the indexes.
+	s.SetName("MyService").As((*testRepository)(nil))
+	assert.NotContains(t, ctn.byName, "di.testSQLRepository")
+	assert.Equal(t, []*Service{s}, ctn.byName["MyService"])
+	assert.Equal(t, []*Service{s}, ctn.byType[reflect.TypeOf((*testRepository)(nil)).Elem()])
+}
+
+func TestContainer_AddInstance(t *testing.T) {
+	t.Run("Given Instance", func(t *testing.T) {
+		instance := &testDependency{}
+
+		ctn := NewContainer()
+		s := ctn.AddInstance(instance)
+		assert.Same(t, s, ctn.services[0])
+		assert.Equal(t, "di.testDependency", s.Name())
+		assert.Equal(t, LifetimeSingleton, s.lifetime)
+
+		v := GetService[*testDependency](ctn)
+		assert.Same(t, instance, v)
+	})
+
+	t.Run("Given Nil Instance", func(t *testing.T) {
+		ctn := NewContainer()
+
+		assert.Panics(t, func() {
+			_ = ctn.AddInstance(nil)
+		})
+	})
+}
+
+type testConfig struct {
+	Addr string
+}
+
+func TestContainer_AddValue(t *testing.T) {
+	t.Run("Given String Value", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddValue("postgres://localhost")
+		assert.Equal(t, "string", s.Name())
+
+		assert.Equal(t, "postgres://localhost", GetService[string](ctn))
+		assert.Equal(t, "postgres://localhost", ctn.GetService("string"))
+
+		// The value should be resolved without a constructor.
+		assert.Nil(t, s.ctor)
+		assert.Empty(t, s.dependencyNames())
+	})
+
+	t.Run("Where Container Is Cleaned Or Cloned", func(t *testing.T) {
+		cfg := &testConfig{Addr: ":8080"}
+
+		ctn := NewContainer()
+		ctn.AddValue(cfg)
+		clone := ctn.Clone()
+
+		assert.NoError(t, ctn.Clean(context.Background()))
+		assert.Same(t, cfg, GetService[*testConfig](ctn))
+		assert.Same(t, cfg, GetService[*testConfig](clone))
+		assert.NoError(t, clone.Validate())
+	})
+
+	t.Run("Given Struct Value", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddValue(testConfig{Addr: ":8080"})
+
+		assert.Equal(t, testConfig{Addr: ":8080"}, GetService[testConfig](ctn))
+		assert.Equal(t, testConfig{Addr: ":8080"}, ctn.GetService("di.testConfig"))
+	})
+
+	t.Run("Given Named Values", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetStrict(true)
+		ctn.AddNamedValue("dsn", "postgres://localhost")
+		ctn.AddNamedValue("region", "eu-west-2")
+
+		assert.Equal(t, "postgres://localhost", ctn.GetService("dsn"))
+		assert.Equal(t, "eu-west-2", ctn.GetService("region"))
+
+		// In strict mode, names must be unique.
+		assert.Panics(t, func() {
+			ctn.AddNamedValue("dsn", "mysql://localhost")
+		})
+	})
+}
+
+func TestContainer_AddNamed(t *testing.T) {
+	t.Run("Given Multiple Names", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddNamed([]string{"db", "primaryDB", "legacyDB"}, func() *testService {
+			return &testService{}
+		}).AsSingleton()
+		assert.Equal(t, "db", s.Name())
+
+		v := ctn.GetService("db")
+		assert.Same(t, v, ctn.GetService("primaryDB"))
+		assert.Same(t, v, ctn.GetService("legacyDB"))
+		assert.Same(t, v, GetService[*testService](ctn))
+		assert.Len(t, ctn.services, 1)
+	})
+
+	t.Run("Given No Names", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddNamed(nil, func() *testService {
+			return &testService{}
+		})
+
+		assert.Equal(t, "di.testService", s.Name())
+	})
+
+	t.Run("Given Name In Use In Strict Mode", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetStrict(true)
+		ctn.AddNamedValue("legacyDB", "postgres://localhost")
+
+		assert.Panics(t, func() {
+			ctn.AddNamed([]string{"db", "legacyDB"}, func() *testService {
+				return &testService{}
+			})
+		})
+		assert.Len(t, ctn.services, 1)
+	})
+}
+
+func TestContainer_Clone(t *testing.T) {
+	builds := 0
+	ctn := NewContainer()
+	ctn.AddSingleton(func() *testService {
+		builds++
+		return &testService{x: builds}
+	}).SetMeta("owner", "payments")
+	v := GetService[*testService](ctn)
+
+	clone := ctn.Clone()
+	cv := GetService[*testService](clone)
+	assert.NotSame(t, v, cv)
+	assert.Equal(t, 2, builds)
+	assert.Same(t, cv, GetService[*testService](clone))
+	assert.Same(t, v, GetService[*testService](ctn))
+
+	owner, _ := clone.services[0].Meta("owner")
+	assert.Equal(t, "payments", owner)
+
+	// Registering services in the clone should not affect the original.
+	clone.AddValue("clone")
+	assert.Len(t, clone.services, 2)
+	assert.Len(t, ctn.services, 1)
+	_, err := ctn.TryGetService("string")
+	assert.ErrorIs(t, err, ErrServiceNotFound)
+}
+
+func TestContainer_Clean_DependencyOrder(t *testing.T) {
+	disposed := make([]string, 0)
+	dispose := func(ctx context.Context, i interface{}) {
+		switch i.(type) {
+		case *testService:
+			disposed = append(disposed, "A")
+		case *testDependency:
+			disposed = append(disposed, "B")
+		}
+	}
+
+	ctn := NewContainer()
+
+	// B is registered before A, but A depends on B, so should be disposed first.
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton().SetDispose(dispose)
+	ctn.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).AsSingleton().SetDispose(dispose)
+
+	_ = ctn.GetService("di.testService")
+
+	assert.Nil(t, ctn.Clean(context.Background()))
+	assert.Equal(t, []string{"A", "B"}, disposed)
+}
+
+func TestContainer_Clean_WithErrors(t *testing.T) {
+	err1 := errors.New("failed to flush")
+	err2 := errors.New("failed to close")
+	disposed := false
+
+	ctn := NewContainer()
+	ctn.AddInstance(&testDependency{}).SetDisposeE(func(ctx context.Context, i interface{}) error {
+		return err1
+	})
+	ctn.AddInstance(&testDependency2{}).SetDispose(func(ctx context.Context, i interface{}) {
+		disposed = true
+	})
+	ctn.AddInstance(&testService{}).SetDisposeE(func(ctx context.Context, i interface{}) error {
+		return err2
+	})
+
+	err := ctn.Clean(context.Background())
+	assert.ErrorIs(t, err, err1)
+	assert.ErrorIs(t, err, err2)
+	assert.Contains(t, err.Error(), "failed to dispose di.testDependency")
+	assert.Contains(t, err.Error(), "failed to dispose di.testService")
+	assert.True(t, disposed)
+	for _, s := range ctn.services {
+		assert.Nil(t, s.instance())
+	}
+}
+
+func TestContainer_Clear(t *testing.T) {
+	t.Run("Given Built Singleton", func(t *testing.T) {
+		disposed := false
+
+		ctn := NewContainer()
+		s := ctn.AddSingleton(func() *testService {
+			return &testService{x: 1}
+		}).SetDispose(func(ctx context.Context, i interface{}) {
+			disposed = true
+		})
+		_ = ctn.GetService("di.testService")
+
+		assert.NoError(t, ctn.Clear(context.Background()))
+		assert.True(t, disposed)
+		assert.Nil(t, s.instance())
+		assert.Empty(t, ctn.services)
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.Panics(t, func() {
+			_ = GetService[*testService](ctn)
+		})
+
+		// Should be able to register services again.
+		ctn.AddSingleton(func() *testService {
+			return &testService{x: 2}
+		})
+		assert.Equal(t, 2, GetService[*testService](ctn).x)
+	})
+
+	t.Run("Where Dispose Fails", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddInstance(&testService{}).SetDisposeE(func(ctx context.Context, i interface{}) error {
+			return assert.AnError
+		})
+
+		assert.ErrorIs(t, ctn.Clear(context.Background()), assert.AnError)
+		assert.Empty(t, ctn.services)
+	})
+
+	t.Run("Where Container Is Frozen", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddInstance(&testService{})
+		ctn.Freeze()
+
+		assert.Panics(t, func() {
+			_ = ctn.Clear(context.Background())
+		})
+		assert.Len(t, ctn.services, 1)
+	})
+}
+
+func TestContainer_CleanParallel(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		disposed []string
+		barrier  sync.WaitGroup
+	)
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		disposed = append(disposed, name)
+	}
+
+	// Each dependency waits for the other to start disposing, so
+	// will only return if they are disposed concurrently.
+	barrier.Add(2)
+	independent := func(name string) DisposeFuncE {
+		return func(ctx context.Context, i interface{}) error {
+			barrier.Done()
+
+			done := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				record(name)
+				return nil
+			case <-time.After(time.Second):
+				return fmt.Errorf("%s was not disposed concurrently", name)
+			}
+		}
+	}
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton().SetDisposeE(independent("B"))
+	ctn.AddService(func() *testDependency2 {
+		return &testDependency2{}
+	}).AsSingleton().SetDisposeE(independent("C"))
+	ctn.AddService(func(d *testDependency, d2 *testDependency2) *testService {
+		return &testService{dep: d}
+	}).AsSingleton().SetDispose(func(ctx context.Context, i interface{}) {
+		record("A")
+	})
+
+	_ = ctn.GetService("di.testService")
+
+	assert.NoError(t, ctn.CleanParallel(context.Background()))
+	assert.Len(t, disposed, 3)
+	assert.Equal(t, "A", disposed[0])
+	assert.ElementsMatch(t, []string{"B", "C"}, disposed[1:])
+	for _, s := range ctn.services {
+		assert.Nil(t, s.instance())
+	}
+}
+
+func TestContainer_VerifyAllResolvable(t *testing.T) {
+	t.Run("Where All Services Resolve", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsSingleton()
+		ctn.AddService(func(ctx context.Context) *testDependency2 { return &testDependency2{} }).AsScoped()
+		ctn.AddService(func(d *testDependency) *testService { return &testService{dep: d} })
+
+		err := ctn.VerifyAllResolvable(context.Background())
+		assert.Nil(t, err)
+	})
+
+	t.Run("Where A Service Fails", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} })
+		ctn.AddService(func() (*testDependency2, error) { return nil, assert.AnError }).SetName("BrokenService")
+		ctn.AddService(func(d *testDependency) *testService { return &testService{dep: d} })
+
+		err := ctn.VerifyAllResolvable(context.Background())
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "BrokenService")
+		assert.Contains(t, err.Error(), assert.AnError.Error())
+		assert.NotContains(t, err.Error(), "di.testService")
+	})
+
+	t.Run("Given Scoped Service With Dispose", func(t *testing.T) {
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "verify")
+
+		disposed := 0
+		ctn := NewContainer()
+		ctn.AddScoped(func(c context.Context) *testDependency {
+			assert.Equal(t, "verify", c.Value(ctxKey{}))
+			return &testDependency{}
+		}).SetDispose(func(c context.Context, i interface{}) {
+			assert.Same(t, ctx, c)
+			disposed++
+		})
+		ctn.AddService(func(c context.Context) *testService {
+			assert.Equal(t, "verify", c.Value(ctxKey{}))
+			return &testService{}
+		})
+
+		assert.NoError(t, ctn.VerifyAllResolvable(ctx))
+		assert.Equal(t, 1, disposed)
+	})
+
+	t.Run("Given Transient Service With Scoped Dependency", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency { return &testDependency{} })
+		ctn.AddTransient(func(d *testDependency) *testService { return &testService{dep: d} })
+
+		assert.NoError(t, ctn.VerifyAllResolvable(context.Background()))
+	})
+}
+
+func TestContainer_Clean(t *testing.T) {
+	hasBeenDisposed := false
+	testCtx := context.Background()
+	testValue := "My String"
+
+	ctn := NewContainer()
+	ctn.AddService(func() interface{} {
+		return testValue
+	}).
+		AsSingleton().
+		SetDispose(func(ctx context.Context, i interface{}) {
+			assert.Equal(t, testCtx, ctx)
+			assert.Equal(t, testValue, i)
+
+			// Proves that the dispose has only been called once.
+			assert.False(t, hasBeenDisposed)
+
+			hasBeenDisposed = true
+		}).
+		SetName("MyService")
+
+	// Builds the service
+	_ = ctn.GetService("MyService")
+
+	ctn.Clean(testCtx)
+
+	assert.True(t, hasBeenDisposed)
+	assert.Nil(t, ctn.services[0].instance())
+}
+
+type testLogger interface {
+	Log() string
+}
+
+type testBaseLogger struct{}
+
+func (testBaseLogger) Log() string { return "log" }
+
+type testPrefixLogger struct {
+	prefix string
+	inner  testLogger
+}
+
+func (l *testPrefixLogger) Log() string { return l.prefix + l.inner.Log() }
+
+func TestContainer_Decorate(t *testing.T) {
+	t.Run("Given Chained Decorators", func(t *testing.T) {
+		ctn := NewContainer()
+		Register[testLogger](ctn, func() testBaseLogger { return testBaseLogger{} })
+		ctn.Decorate("di.testLogger", func(l testLogger) *testPrefixLogger {
+			return &testPrefixLogger{prefix: "a:", inner: l}
+		})
+		ctn.Decorate("di.testLogger", func(l testLogger, d *testDependency) *testPrefixLogger {
+			assert.NotNil(t, d)
+			return &testPrefixLogger{prefix: "b:", inner: l}
+		})
+		ctn.AddService(func() *testDependency { return &testDependency{} })
+
+		v := GetService[testLogger](ctn)
+		assert.Equal(t, "b:a:log", v.Log())
+	})
+
+	t.Run("Given Singleton Service", func(t *testing.T) {
+		decorations := 0
+
+		ctn := NewContainer()
+		Register[testLogger](ctn, func() testBaseLogger { return testBaseLogger{} }).AsSingleton()
+		ctn.Decorate("di.testLogger", func(l testLogger) *testPrefixLogger {
+			decorations++
+			return &testPrefixLogger{prefix: "a:", inner: l}
+		})
+
+		v1 := GetService[testLogger](ctn)
+		v2 := GetService[testLogger](ctn)
+		assert.Same(t, v1, v2)
+		assert.Equal(t, 1, decorations)
+	})
+
+	t.Run("Given Invalid Decorator", func(t *testing.T) {
+		ctn := NewContainer()
+		Register[testLogger](ctn, func() testBaseLogger { return testBaseLogger{} })
+
+		assert.Panics(t, func() {
+			ctn.Decorate("di.testLogger", func() *testPrefixLogger {
+				return &testPrefixLogger{}
+			})
+		})
+		assert.Panics(t, func() {
+			ctn.Decorate("di.testLogger", func(l testLogger) *testDependency {
+				return &testDependency{}
+			})
+		})
+	})
+
+	t.Run("Given Service In Parent Container", func(t *testing.T) {
+		parent := NewContainer()
+		Register[testLogger](parent, func() testBaseLogger { return testBaseLogger{} })
+		child := parent.CreateChild()
+
+		assert.PanicsWithError(t, "container: can not decorate di.testLogger, as it is registered in a parent container", func() {
+			child.Decorate("di.testLogger", func(l testLogger) *testPrefixLogger {
+				return &testPrefixLogger{prefix: "a:", inner: l}
+			})
+		})
+		assert.Equal(t, "log", GetService[testLogger](parent).Log())
+	})
+
+	t.Run("Given Built Service", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddNamedValue("prefix", "a:")
+		ctn.AddInstance(&testPrefixLogger{prefix: "b:", inner: testBaseLogger{}})
+
+		assert.PanicsWithError(t, "container: can not decorate prefix, as it has already been built", func() {
+			ctn.Decorate("prefix", func(s string) string { return s })
+		})
+		assert.PanicsWithError(t, "container: can not decorate di.testPrefixLogger, as it has already been built", func() {
+			ctn.Decorate("di.testPrefixLogger", func(l *testPrefixLogger) *testPrefixLogger {
+				return &testPrefixLogger{prefix: "c:", inner: l}
+			})
+		})
+	})
+
+	t.Run("Where Decorator Fails", func(t *testing.T) {
+		errTest := errors.New("test")
+
+		ctn := NewContainer()
+		Register[testLogger](ctn, func() testBaseLogger { return testBaseLogger{} })
+		ctn.Decorate("di.testLogger", func(l testLogger) (testLogger, error) {
+			return nil, errTest
+		})
+
+		_, err := ctn.TryGetService("di.testLogger")
+		assert.ErrorIs(t, err, errTest)
+	})
+}
+
+func TestContainer_GetService_AmbiguousDependency(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("First")
+	ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("Second")
+	ctn.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).SetName("MyService")
+
+	defer func() {
+		err := recover().(error)
+		assert.ErrorIs(t, err, ErrAmbiguousDependency)
+		assert.Contains(t, err.Error(), "*di.testDependency matches First, Second")
+	}()
+
+	_ = ctn.GetService("MyService")
+}
+
+func TestContainer_SetRecoverPanics(t *testing.T) {
+	ctor := func() *testService {
+		panic("something went wrong")
+	}
+
+	t.Run("Where Enabled", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(ctor).SetName("MyService")
+
+		defer func() {
+			err, ok := recover().(error)
+			assert.True(t, ok)
+			assert.Contains(t, err.Error(), "MyService panicked, something went wrong")
+		}()
+
+		_ = ctn.GetService("MyService")
+	})
+
+	t.Run("Where Panic Value Is An Error", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			panic(assert.AnError)
+		}).SetName("MyService")
+
+		_, err := ctn.TryGetService("MyService")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Where Disabled", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetRecoverPanics(false)
+		ctn.AddService(ctor).SetName("MyService")
+
+		assert.PanicsWithValue(t, "something went wrong", func() {
+			_ = ctn.GetService("MyService")
+		})
+	})
+}
+
+func TestContainer_SetStrict(t *testing.T) {
+	t.Run("Where Strict", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetStrict(true)
+		ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("cache")
+		s := ctn.AddService(func() *testDependency2 { return &testDependency2{} })
+
+		assert.PanicsWithError(t, "container: can not name *di.testDependency2 cache, as the name is used by *di.testDependency", func() {
+			s.SetName("cache")
+		})
+		assert.Equal(t, "di.testDependency2", s.Name())
+
+		// Services of the same type can be given distinct names.
+		ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("session")
+		assert.IsType(t, &testDependency{}, ctn.GetService("session"))
+	})
+
+	t.Run("Where Not Strict", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("cache")
+		ctn.AddService(func() *testDependency2 { return &testDependency2{} }).SetName("cache")
+
+		// The last registered service wins.
+		assert.IsType(t, &testDependency2{}, ctn.GetService("cache"))
+	})
+}
+
+func TestContainer_CreateChild(t *testing.T) {
+	parentDep := &testDependency{}
+
+	parent := NewContainer()
+	parent.AddInstance(parentDep)
+	parent.AddService(func() *testDependency2 {
+		return &testDependency2{}
+	}).AsSingleton()
+	parent.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).SetName("MyService")
+
+	child := parent.CreateChild()
+	childDep := &testDependency{}
+	child.AddInstance(childDep)
+	child.AddService(func(d *testDependency, d2 *testDependency2) *testSQLRepository {
+		assert.Same(t, childDep, d)
+		return &testSQLRepository{}
+	})
+
+	// The child's registration should shadow the parent's.
+	assert.Same(t, childDep, child.GetService("di.testDependency"))
+	assert.Same(t, parentDep, parent.GetService("di.testDependency"))
+	assert.NotNil(t, child.GetService("di.testSQLRepository"))
+
+	// Services resolved from the parent should be built by the parent.
+	svc := child.GetService("MyService").(*testService)
+	assert.Same(t, parentDep, svc.dep)
+	assert.Same(t, parent.GetService("di.testDependency2"), child.GetService("di.testDependency2"))
+
+	assert.Panics(t, func() {
+		_ = parent.GetService("di.testSQLRepository")
+	})
+}
+
+func TestContainer_ResolveContext(t *testing.T) {
+	type ctxKey struct{}
+
+	t.Run("Where container has a base context", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, 42)
+
+		ctn := NewContainer(WithContext(ctx))
+		ctn.AddService(func(ctx context.Context) *testService {
+			return &testService{x: ctx.Value(ctxKey{}).(int)}
+		})
+
+		svc := ctn.GetService("di.testService").(*testService)
+		assert.Equal(t, 42, svc.x)
+		assert.Equal(t, 42, ctn.CreateScope().GetService("di.testService").(*testService).x)
+		assert.Equal(t, 42, ctn.CreateChild().GetService("di.testService").(*testService).x)
+	})
+
+	t.Run("Where container has no base context", func(t *testing.T) {
+		var v context.Context
+
+		ctn := NewContainer()
+		ctn.AddService(func(ctx context.Context) *testService {
+			v = ctx
+			return &testService{}
+		})
+
+		_ = ctn.GetService("di.testService")
+		assert.Equal(t, context.Background(), v)
+	})
+}
+
+func TestContainer_GetServiceWithContext(t *testing.T) {
+	type ctxKey struct{}
+
+	var depValue interface{}
+
+	ctn := NewContainer(WithContext(context.WithValue(context.Background(), ctxKey{}, 1)))
+	ctn.AddService(func(ctx context.Context) *testDependency2 {
+		depValue = ctx.Value(ctxKey{})
+		return &testDependency2{}
+	})
+	ctn.AddService(func(ctx context.Context, d *testDependency2) *testService {
+		return &testService{x: ctx.Value(ctxKey{}).(int)}
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, 2)
+	svc := ctn.GetServiceWithContext(ctx, "di.testService").(*testService)
+	assert.Equal(t, 2, svc.x)
+	assert.Equal(t, 2, depValue)
+
+	// Should resolve from the parent, with the given context.
+	svc = ctn.CreateChild().GetServiceWithContext(ctx, "di.testService").(*testService)
+	assert.Equal(t, 2, svc.x)
+
+	svc = ctn.GetService("di.testService").(*testService)
+	assert.Equal(t, 1, svc.x)
+	assert.Equal(t, 1, depValue)
+}
+
+func TestContainer_WithLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ctn := NewContainer(WithLogger(logger))
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton()
+	ctn.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	})
+
+	_ = ctn.GetService("di.testService")
+	_ = ctn.GetService("di.testService")
+
+	type entry struct {
+		Msg      string `json:"msg"`
+		Name     string `json:"name"`
+		Lifetime string `json:"lifetime"`
+		Depth    int    `json:"depth"`
+	}
+
+	entries := make([]entry, 0)
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var e entry
+		assert.NoError(t, dec.Decode(&e))
+		entries = append(entries, e)
+	}
+
+	// The singleton should only be logged when it is built.
+	assert.Equal(t, []entry{
+		{Msg: "service built", Name: "di.testDependency", Lifetime: "Singleton", Depth: 1},
+		{Msg: "service built", Name: "di.testService", Lifetime: "Transient", Depth: 0},
+		{Msg: "service built", Name: "di.testService", Lifetime: "Transient", Depth: 0},
+	}, entries)
+}
+
+func TestContainer_SetOnBuild(t *testing.T) {
+	type call struct {
+		ctx  context.Context
+		name string
+		err  error
+	}
+
+	testErr := errors.New("test error")
+
+	calls := make([]call, 0)
+	ctn := NewContainer()
+	ctn.SetOnBuild(func(ctx context.Context, name string) func(err error) {
+		return func(err error) {
+			calls = append(calls, call{ctx: ctx, name: name, err: err})
+		}
+	})
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.AddService(func(d *testDependency) (*testService, error) {
+		return nil, testErr
+	}).AsScoped()
+
+	_ = ctn.GetService("di.testDependency")
+	assert.Len(t, calls, 1)
+	assert.Equal(t, "di.testDependency", calls[0].name)
+	assert.Equal(t, context.Background(), calls[0].ctx)
+	assert.NoError(t, calls[0].err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls = calls[:0]
+	assert.Panics(t, func() {
+		_ = ctn.CreateScopeWithContext(ctx).GetService("di.testService")
+	})
+
+	// The dependency is built before the hook's callback for the service
+	// is called, and the Scope's context is given to both.
+	assert.Len(t, calls, 2)
+	assert.Equal(t, "di.testDependency", calls[0].name)
+	assert.Equal(t, ctx, calls[0].ctx)
+	assert.NoError(t, calls[0].err)
+	assert.Equal(t, "di.testService", calls[1].name)
+	assert.Equal(t, ctx, calls[1].ctx)
+	assert.ErrorIs(t, calls[1].err, testErr)
+}
+
+func TestContainer_WarmUp(t *testing.T) {
+	t.Run("Where all singletons build", func(t *testing.T) {
+		var depBuilds, svcBuilds, transientBuilds int32
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			atomic.AddInt32(&depBuilds, 1)
+			return &testDependency{}
+		}).AsSingleton()
+		ctn.AddService(func(d *testDependency) *testService {
+			atomic.AddInt32(&svcBuilds, 1)
+			return &testService{dep: d}
+		}).AsSingleton()
+		ctn.AddService(func(d *testDependency) *testSQLRepository {
+			return &testSQLRepository{}
+		}).AsSingleton()
+		ctn.AddService(func() *testDependency2 {
+			atomic.AddInt32(&transientBuilds, 1)
+			return &testDependency2{}
+		})
+
+		err := ctn.WarmUp(context.Background())
+		assert.NoError(t, err)
+
+		// Shared dependencies should only be built once,
+		// and transient services should not be built.
+		assert.Equal(t, int32(1), depBuilds)
+		assert.Equal(t, int32(1), svcBuilds)
+		assert.Equal(t, int32(0), transientBuilds)
+
+		svc := ctn.GetService("di.testService").(*testService)
+		assert.Same(t, ctn.GetService("di.testDependency"), svc.dep)
+		assert.Equal(t, int32(1), svcBuilds)
+	})
+
+	t.Run("Where singletons fail to build", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() (*testDependency, error) {
+			return nil, errors.New("dependency error")
+		}).AsSingleton()
+		ctn.AddService(func() (*testDependency2, error) {
+			return nil, errors.New("dependency2 error")
+		}).AsSingleton()
+
+		err := ctn.WarmUp(context.Background())
+		assert.ErrorContains(t, err, "dependency error")
+		assert.ErrorContains(t, err, "dependency2 error")
+	})
+
+	t.Run("Where singletons depend on each other", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(b *testCycleB) *testCycleA {
+			return &testCycleA{}
+		}).AsSingleton()
+		ctn.AddService(func(a *testCycleA) *testCycleB {
+			return &testCycleB{}
+		}).AsSingleton()
+
+		err := ctn.WarmUp(context.Background())
+		assert.ErrorIs(t, err, ErrCircularDependency)
+	})
+
+	t.Run("Where context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			t.Fatal("singleton should not be built")
+			return nil
+		}).AsSingleton()
+
+		err := ctn.WarmUp(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Where context is cancelled while building", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var svcBuilds int32
+		ctn := NewContainer()
+		ctn.AddService(func(ctx context.Context) *testDependency {
+			cancel()
+			return &testDependency{}
+		}).AsSingleton()
+		ctn.AddService(func(d *testDependency) *testService {
+			atomic.AddInt32(&svcBuilds, 1)
+			return &testService{dep: d}
+		}).AsSingleton()
+		ctn.AddService(func(ctx context.Context) *testDependency2 {
+			// Constructors should be given the context.
+			<-ctx.Done()
+			return &testDependency2{}
+		}).AsSingleton()
+
+		err := ctn.WarmUp(ctx)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, int32(0), svcBuilds)
+	})
+}
+
+func TestContainer_SetOnResolve(t *testing.T) {
+	type call struct {
+		name     string
+		lifetime ServiceLifetime
+		cached   bool
+	}
+
+	calls := make([]call, 0)
+	ctn := NewContainer()
+	ctn.SetOnResolve(func(name string, lifetime ServiceLifetime, cached bool) {
+		calls = append(calls, call{name: name, lifetime: lifetime, cached: cached})
+	})
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton()
+	ctn.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).AsScoped()
+
+	_ = ctn.GetService("di.testDependency")
+	_ = ctn.GetService("di.testDependency")
+	assert.Equal(t, []call{
+		{name: "di.testDependency", lifetime: LifetimeSingleton, cached: false},
+		{name: "di.testDependency", lifetime: LifetimeSingleton, cached: true},
+	}, calls)
+
+	calls = calls[:0]
+	scope := ctn.CreateScope()
+	_ = scope.GetService("di.testService")
+	_ = scope.GetService("di.testService")
+	assert.Equal(t, []call{
+		{name: "di.testService", lifetime: LifetimeScoped, cached: false},
+		{name: "di.testDependency", lifetime: LifetimeSingleton, cached: true},
+		{name: "di.testService", lifetime: LifetimeScoped, cached: true},
+	}, calls)
+}
+
+func TestContainer_ResolveWith(t *testing.T) {
+	type tenantID string
+
+	t.Run("Given Override", func(t *testing.T) {
+		dep := &testDependency{}
+
+		ctn := NewContainer()
+		ctn.AddInstance(dep)
+		ctn.AddService(func(d *testDependency, id tenantID) *testService {
+			return &testService{dep: d, x: len(id)}
+		}).AsSingleton().SetName("repo")
+
+		v, err := ctn.ResolveWith("repo", tenantID("tenant"))
+		assert.NoError(t, err)
+		assert.Same(t, dep, v.(*testService).dep)
+		assert.Equal(t, 6, v.(*testService).x)
+
+		// The instance should not be cached as the singleton.
+		v2, err := ctn.ResolveWith("repo", tenantID("other"))
+		assert.NoError(t, err)
+		assert.NotSame(t, v, v2)
+		assert.Equal(t, 5, v2.(*testService).x)
+		assert.Nil(t, ctn.getServiceInfo("repo").instance())
+	})
+
+	t.Run("Where Parameter Is Not Overridden", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(d *testDependency, id tenantID) *testService {
+			return &testService{}
+		}).SetName("repo")
+
+		_, err := ctn.ResolveWith("repo", tenantID("tenant"))
+		assert.EqualError(t, err, "container: failed to build repo, container: failed to resolve *di.testDependency")
+	})
+
+	t.Run("Where Service Is Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddScoped(func(id tenantID) *testService {
+			return &testService{x: len(id)}
+		}).SetName("repo")
+
+		_, err := ctn.ResolveWith("repo", tenantID("tenant"))
+		assert.ErrorIs(t, err, ErrScopedOutsideScope)
+	})
+
+	t.Run("Where Service Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		_, err := ctn.ResolveWith("repo")
+		assert.EqualError(t, err, "container: could not find service, repo")
+	})
+}
+
+func TestContainer_RebuildSingleton(t *testing.T) {
+	t.Run("Where Service Is Singleton", func(t *testing.T) {
+		disposed := make([]interface{}, 0)
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			return &testService{}
+		}).AsSingleton().SetDispose(func(ctx context.Context, i interface{}) {
+			disposed = append(disposed, i)
+		})
+
+		old := ctn.GetService("di.testService")
+
+		v, err := ctn.RebuildSingleton("di.testService", context.Background())
+		assert.NoError(t, err)
+		assert.NotSame(t, old, v)
+		assert.Same(t, v, ctn.GetService("di.testService"))
+		assert.Equal(t, []interface{}{old}, disposed)
+	})
+
+	t.Run("Where Build Fails", func(t *testing.T) {
+		fail := false
+
+		ctn := NewContainer()
+		ctn.AddService(func() (*testService, error) {
+			if fail {
+				return nil, assert.AnError
+			}
+			return &testService{}, nil
+		}).AsSingleton()
+
+		old := ctn.GetService("di.testService")
+		fail = true
+
+		_, err := ctn.RebuildSingleton("di.testService", context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+
+		// The current instance should be kept.
+		assert.Same(t, old, ctn.GetService("di.testService"))
+	})
+
+	t.Run("Where Service Is Used During Rebuild", func(t *testing.T) {
+		building := make(chan struct{})
+		release := make(chan struct{})
+		var calls atomic.Int32
+
+		ctn := NewContainer()
+		svc := ctn.AddService(func() *testService {
+			if calls.Add(1) == 2 {
+				close(building)
+				<-release
+			}
+			return &testService{}
+		}).AsSingleton().SetMeta("version", 1)
+
+		old := ctn.GetService("di.testService")
+
+		done := make(chan interface{})
+		go func() {
+			v, _ := ctn.RebuildSingleton("di.testService", context.Background())
+			done <- v
+		}()
+		<-building
+
+		// The service should not be locked while the new instance is built.
+		v, ok := svc.Meta("version")
+		assert.True(t, ok)
+		assert.Equal(t, 1, v)
+		assert.Same(t, old, ctn.GetService("di.testService"))
+
+		close(release)
+		assert.Same(t, <-done, ctn.GetService("di.testService"))
+	})
+
+	t.Run("Where Service Is Not Singleton", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			return &testService{}
+		})
+
+		_, err := ctn.RebuildSingleton("di.testService", context.Background())
+		assert.EqualError(t, err, "container: can not rebuild di.testService, as it is not a singleton")
+	})
+
+	t.Run("Where Service Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+
+		_, err := ctn.RebuildSingleton("di.testService", context.Background())
+		assert.EqualError(t, err, "container: could not find service, di.testService")
+	})
+}
+
+func TestContainer_CleanTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	disposed := make([]interface{}, 0)
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton().SetDispose(func(ctx context.Context, i interface{}) {
+		disposed = append(disposed, i)
+	})
+	ctn.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).AsSingleton().SetDispose(func(ctx context.Context, i interface{}) {
+		// Blocks until the test ends, ignoring ctx.
+		<-release
+	})
+
+	svc := ctn.GetService("di.testService").(*testService)
+
+	err := ctn.CleanTimeout(context.Background(), 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrDisposeTimeout)
+	assert.ErrorContains(t, err, "di.testService")
+	assert.NotContains(t, err.Error(), "di.testDependency")
+
+	// Services after the one which timed out should still be disposed.
+	assert.Equal(t, []interface{}{svc.dep}, disposed)
+
+	// The service should be built again, regardless of the abandoned dispose.
+	assert.NotSame(t, svc, ctn.GetService("di.testService"))
+}
+
+func TestContainer_CleanTimeout_CancelledContext(t *testing.T) {
+	called := false
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton().SetDispose(func(ctx context.Context, i interface{}) {
+		called = true
+	})
+	ctn.AddService(func() *testService {
+		return &testService{}
+	}).AsSingleton()
+	_ = ctn.GetService("di.testDependency")
+	_ = ctn.GetService("di.testService")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Only services with a DisposeFunc should be reported as skipped.
+	err := ctn.CleanTimeout(ctx, time.Second)
+	assert.ErrorIs(t, err, ErrDisposeSkipped)
+	assert.NotErrorIs(t, err, ErrDisposeTimeout)
+	assert.EqualError(t, err, "service: skipped disposing di.testDependency, context canceled")
+	assert.False(t, called)
+}
@@ -0,0 +1,98 @@
+package di
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// serviceDescription is the JSON representation of a Service, used by Describe.
+type serviceDescription struct {
+	Name         string   `json:"name"`
+	Package      string   `json:"package"`
+	Type         string   `json:"type"`
+	Lifetime     string   `json:"lifetime"`
+	Key          string   `json:"key,omitempty"`
+	Tags         []string `json:"tags"`
+	Dependencies []string `json:"dependencies"`
+}
+
+// Describe returns a JSON description of the services in the container, in
+// registration order. This includes each service's name, type, lifetime and
+// the types of its dependencies. No services are built.
+//
+// The output is deterministic, so can be used for tooling and snapshot tests.
+func (ctn *Container) Describe() ([]byte, error) {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	descs := make([]serviceDescription, len(ctn.services))
+	for i, s := range ctn.services {
+		tags := make([]string, len(s.groups))
+		copy(tags, s.groups)
+
+		descs[i] = serviceDescription{
+			Name:         s.name,
+			Package:      pkgPath(s.typ),
+			Type:         s.typ.String(),
+			Lifetime:     s.currentLifetime().String(),
+			Key:          s.key,
+			Tags:         tags,
+			Dependencies: s.dependencyNames(),
+		}
+	}
+
+	return json.Marshal(descs)
+}
+
+// String returns a human readable list of the services in the container, in
+// registration order, with each service's name, type, lifetime, whether its
+// singleton instance has been built and the types of its dependencies. No
+// services are built, so this can be used to debug a failed resolution:
+//
+//	di.Handler *di.Handler Transient [*di.Store]
+//	di.Store *di.Store Singleton (built) []
+func (ctn *Container) String() string {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	var b strings.Builder
+	for _, s := range ctn.services {
+		built := ""
+		if s.instance() != nil {
+			built = " (built)"
+		}
+
+		fmt.Fprintf(&b, "%s %s %s%s [%s]\n", s.name, s.typ.String(), s.currentLifetime().String(), built,
+			strings.Join(s.dependencyNames(), ", "))
+	}
+
+	return b.String()
+}
+
+// pkgPath returns the package path of the type t, or the
+// type it points to, if t is a pointer.
+func pkgPath(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.PkgPath()
+}
+
+// ForEach is used to call fn with the name, lifetime and type of each service
+// registered in the container, in registration order. No services are built.
+//
+// The services are copied before fn is called, so fn can safely call back into
+// the container, however, services registered by fn are not visited.
+func (ctn *Container) ForEach(fn func(name string, lifetime ServiceLifetime, typ reflect.Type)) {
+	ctn.mu.RLock()
+	svcs := make([]*Service, len(ctn.services))
+	copy(svcs, ctn.services)
+	ctn.mu.RUnlock()
+
+	for _, s := range svcs {
+		fn(s.Name(), s.currentLifetime(), s.typ)
+	}
+}
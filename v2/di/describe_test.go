@@ -0,0 +1,101 @@
+package di
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_Describe(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsSingleton().InGroup("deps")
+	ctn.AddService(func(d *testDependency, d2 *testDependency2) *testService {
+		return &testService{}
+	}).SetName("MyService").AsScoped()
+
+	data, err := ctn.Describe()
+	assert.Nil(t, err)
+
+	var descs []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &descs))
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"name":         "di.testDependency",
+			"package":      "github.com/reecerussell/simple-di/v2/di",
+			"type":         "*di.testDependency",
+			"lifetime":     "Singleton",
+			"tags":         []interface{}{"deps"},
+			"dependencies": []interface{}{},
+		},
+		{
+			"name":         "MyService",
+			"package":      "github.com/reecerussell/simple-di/v2/di",
+			"type":         "*di.testService",
+			"lifetime":     "Scoped",
+			"tags":         []interface{}{},
+			"dependencies": []interface{}{"*di.testDependency", "*di.testDependency2"},
+		},
+	}, descs)
+
+	// The output should be stable.
+	data2, err := ctn.Describe()
+	assert.Nil(t, err)
+	assert.Equal(t, data, data2)
+}
+
+func TestContainer_ForEach(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddSingleton(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.AddScoped(func() *testService {
+		return &testService{}
+	}).SetName("MyService")
+	ctn.AddTransient(func() *testDependency2 {
+		return &testDependency2{}
+	})
+
+	visited := make(map[string]int)
+	lifetimes := make(map[string]ServiceLifetime)
+	types := make(map[string]reflect.Type)
+	ctn.ForEach(func(name string, lifetime ServiceLifetime, typ reflect.Type) {
+		visited[name]++
+		lifetimes[name] = lifetime
+		types[name] = typ
+
+		// Should be able to call back into the container.
+		ctn.AddInstance(&testService{}).SetName(name + ".copy")
+	})
+
+	assert.Equal(t, map[string]int{
+		"di.testDependency":  1,
+		"MyService":          1,
+		"di.testDependency2": 1,
+	}, visited)
+	assert.Equal(t, LifetimeScoped, lifetimes["MyService"])
+	assert.Equal(t, reflect.TypeOf(&testService{}), types["MyService"])
+	assert.Len(t, ctn.services, 6)
+}
+
+func TestContainer_String(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddSingleton(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.AddScoped(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).SetName("MyService")
+	_ = ctn.GetService("di.testDependency")
+
+	assert.Equal(t, "di.testDependency *di.testDependency Singleton (built) []\n"+
+		"MyService *di.testService Scoped [*di.testDependency]\n", ctn.String())
+
+	// Should be usable with fmt, without building anything.
+	assert.Contains(t, fmt.Sprint(ctn), "MyService")
+	assert.Equal(t, int64(0), ctn.Stats()["MyService"].Builds)
+}
@@ -0,0 +1,68 @@
+package di
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dotEscaper is used to escape strings within a quoted DOT ID.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// ExportDOT returns the dependency graph of the container's services as a
+// Graphviz DOT digraph. Each service is a node, labelled with its name and
+// lifetime, with an edge to each service its constructor depends on. Edges
+// to dependencies resolved lazily, such as factories, are dotted.
+//
+// Dependencies which can not be resolved are shown as a dashed edge, to a
+// dashed node labelled with the dependency's type. No services are built.
+func (ctn *Container) ExportDOT() string {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	ids := make(map[*Service]string, len(ctn.services))
+	for i, s := range ctn.services {
+		ids[s] = fmt.Sprintf("s%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph di {\n")
+
+	for _, s := range ctn.services {
+		fmt.Fprintf(&b, "\t%s [label=\"%s\\n%s\"];\n", ids[s], dotEscaper.Replace(s.Name()), s.currentLifetime())
+	}
+
+	missing := make(map[string]string)
+	for _, s := range ctn.services {
+		for _, dep := range ctn.dependencies(s) {
+			if len(dep.svcs) == 0 && !dep.optional {
+				typ := dep.typ.String()
+				id, ok := missing[typ]
+				if !ok {
+					id = fmt.Sprintf("m%d", len(missing))
+					missing[typ] = id
+					fmt.Fprintf(&b, "\t%s [label=\"%s\", style=dashed];\n", id, dotEscaper.Replace(typ))
+				}
+
+				fmt.Fprintf(&b, "\t%s -> %s [style=dashed];\n", ids[s], id)
+				continue
+			}
+
+			for _, ds := range dep.svcs {
+				// Services from a parent container are not part of the graph.
+				id, ok := ids[ds]
+				if !ok {
+					continue
+				}
+
+				if dep.lazy {
+					fmt.Fprintf(&b, "\t%s -> %s [style=dotted];\n", ids[s], id)
+				} else {
+					fmt.Fprintf(&b, "\t%s -> %s;\n", ids[s], id)
+				}
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_ExportDOT(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddSingleton(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.AddScoped(func(d *testDependency, f func() *testDependency2) *testService {
+		return &testService{dep: d}
+	}).SetName(`"quoted"`)
+	ctn.AddService(func(s *testService, r testRepository) *testDependency2 {
+		return &testDependency2{}
+	})
+
+	expected := `digraph di {
+	s0 [label="di.testDependency\nSingleton"];
+	s1 [label="\"quoted\"\nScoped"];
+	s2 [label="di.testDependency2\nTransient"];
+	s1 -> s0;
+	s1 -> s2 [style=dotted];
+	s2 -> s1;
+	m0 [label="di.testRepository", style=dashed];
+	s2 -> m0 [style=dashed];
+}
+`
+	assert.Equal(t, expected, ctn.ExportDOT())
+}
+
+func TestContainer_ExportDOT_Child(t *testing.T) {
+	parent := NewContainer()
+	parent.AddSingleton(func() *testDependency {
+		return &testDependency{}
+	})
+
+	child := parent.CreateChild()
+	child.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	})
+
+	expected := `digraph di {
+	s0 [label="di.testService\nTransient"];
+}
+`
+	assert.Equal(t, expected, child.ExportDOT())
+}
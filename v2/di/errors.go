@@ -0,0 +1,83 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrServiceNotFound is returned when a service can not be resolved,
+// as there is no service registered with the given name or type.
+var ErrServiceNotFound = errors.New("container: could not find service")
+
+// ErrBuildFailed is returned when a service, or one of its
+// dependencies, fails to build.
+var ErrBuildFailed = errors.New("container: failed to build")
+
+// ErrCircularDependency is returned when a service depends on itself,
+// either directly or through its dependencies.
+var ErrCircularDependency = errors.New("container: circular dependency")
+
+// ErrAmbiguousDependency is returned when a dependency is resolved by
+// its type, but there are multiple services of that type.
+var ErrAmbiguousDependency = errors.New("container: ambiguous dependency")
+
+// ErrMaxDepthExceeded is returned when the chain of dependencies being
+// resolved is deeper than the maximum configured using WithMaxDepth.
+var ErrMaxDepthExceeded = errors.New("container: maximum resolution depth exceeded")
+
+// ErrScopedOutsideScope is returned when a scoped service is resolved
+// from a Container, rather than a Scope created using CreateScope.
+var ErrScopedOutsideScope = errors.New("container: scoped service resolved outside of a scope")
+
+// ErrNameConflict is returned by Container.Merge, using ConflictError, when
+// a service has the same name as a service already registered.
+var ErrNameConflict = errors.New("container: service name conflict")
+
+// ErrDisposeTimeout is returned when a service's DisposeFunc does not
+// return within the time given by Container.CleanTimeout.
+var ErrDisposeTimeout = errors.New("service: dispose timed out")
+
+// ErrDisposeSkipped is returned when a service is not disposed, as the
+// context.Context given to dispose it was already done.
+var ErrDisposeSkipped = errors.New("service: skipped disposing")
+
+// notFoundError is returned when a dependency can not be resolved
+// because there is no service registered for its type, or name.
+type notFoundError struct {
+	typ  reflect.Type
+	key  string
+	name string
+
+	// Used to suggest how the error could be fixed, if not empty.
+	hint string
+}
+
+// Is reports whether target is ErrServiceNotFound, so the error
+// can be identified using errors.Is.
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrServiceNotFound
+}
+
+func (e *notFoundError) Error() string {
+	if e.typ == nil && e.hint != "" {
+		return ErrServiceNotFound.Error() + ", " + e.name + ", " + e.hint
+	}
+
+	if e.typ == nil {
+		return ErrServiceNotFound.Error() + ", " + e.name
+	}
+
+	if e.key != "" {
+		return "container: failed to resolve " + e.typ.String() + " with key " + e.key
+	}
+
+	return "container: failed to resolve " + e.typ.String()
+}
+
+// isNotFound determines whether err is a notFoundError. Wrapped errors are
+// not considered, as these are returned when a transitive dependency is not
+// found, rather than the service itself.
+func isNotFound(err error) bool {
+	_, ok := err.(*notFoundError)
+	return ok
+}
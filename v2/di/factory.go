@@ -0,0 +1,63 @@
+package di
+
+import "reflect"
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// isFactory determines whether the type t is a factory function, which
+// can be injected in place of a service: func() X or func() (X, error).
+func isFactory(t reflect.Type) bool {
+	if t.Kind() != reflect.Func || t.NumIn() != 0 || t.IsVariadic() {
+		return false
+	}
+
+	switch t.NumOut() {
+	case 1:
+		return !isTypeError(t.Out(0))
+	case 2:
+		return !isTypeError(t.Out(0)) && t.Out(1) == errorType
+	default:
+		return false
+	}
+}
+
+// makeFactory is used to create a factory function of type t, which resolves
+// a new instance of the factory's return type, using r, each time it is called.
+//
+// The factory captures r, so a factory injected into a service built within a
+// Scope will resolve scoped services from that Scope, which is safe to do while
+// the Scope is in use. Note that a singleton retains the factory it was first
+// built with, and therefore the Scope with it.
+//
+// If the factory does not return an error, it will panic if the service
+// fails to resolve.
+func makeFactory(t reflect.Type, r resolver) reflect.Value {
+	rt := t.Out(0)
+
+	return reflect.MakeFunc(t, func([]reflect.Value) []reflect.Value {
+		v, err := r.Resolve(rt)
+		if t.NumOut() == 1 {
+			if err != nil {
+				panic(err)
+			}
+
+			return []reflect.Value{valueOf(v, rt)}
+		}
+
+		if err != nil {
+			return []reflect.Value{reflect.Zero(rt), reflect.ValueOf(&err).Elem()}
+		}
+
+		return []reflect.Value{valueOf(v, rt), reflect.Zero(errorType)}
+	})
+}
+
+// valueOf returns the reflect.Value of v, or the zero value
+// of t if v is nil, so that it can be passed as an argument.
+func valueOf(v interface{}, t reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(t)
+	}
+
+	return reflect.ValueOf(v)
+}
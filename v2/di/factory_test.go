@@ -0,0 +1,121 @@
+package di
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testWorkerPool struct {
+	newWorker func() *testService
+	tryWorker func() (*testDependency2, error)
+}
+
+func TestFactory(t *testing.T) {
+	t.Run("Where Dependency Is Registered", func(t *testing.T) {
+		builds := 0
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			builds++
+			return &testService{}
+		})
+		ctn.AddService(func(f func() *testService) *testWorkerPool {
+			return &testWorkerPool{newWorker: f}
+		})
+
+		p := GetService[*testWorkerPool](ctn)
+
+		// The dependency should be built lazily.
+		assert.Equal(t, 0, builds)
+
+		w1 := p.newWorker()
+		w2 := p.newWorker()
+		assert.NotNil(t, w1)
+		assert.NotSame(t, w1, w2)
+		assert.Equal(t, 2, builds)
+	})
+
+	t.Run("Where Dependency Is Not Registered", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(f func() *testService, f2 func() (*testDependency2, error)) *testWorkerPool {
+			return &testWorkerPool{newWorker: f, tryWorker: f2}
+		})
+
+		p := GetService[*testWorkerPool](ctn)
+
+		assert.Panics(t, func() {
+			_ = p.newWorker()
+		})
+
+		w, err := p.tryWorker()
+		assert.Nil(t, w)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Where Dependency Is Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency2 {
+			return &testDependency2{}
+		}).AsScoped()
+		ctn.AddService(func(f func() (*testDependency2, error)) *testWorkerPool {
+			return &testWorkerPool{tryWorker: f}
+		}).AsScoped()
+
+		s := ctn.CreateScope()
+		dep := s.GetService("di.testDependency2")
+		p := s.GetService("di.testWorkerPool").(*testWorkerPool)
+
+		// The factory should resolve from the scope it was built in.
+		w, err := p.tryWorker()
+		assert.Nil(t, err)
+		assert.Same(t, dep, w)
+	})
+}
+
+func TestFactory_Scoped(t *testing.T) {
+	newContainer := func() *Container {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testService { return &testService{} })
+		ctn.AddScoped(func() *testDependency2 { return &testDependency2{} })
+		ctn.AddScoped(func(f func() *testService) *testWorkerPool {
+			return &testWorkerPool{newWorker: f}
+		})
+		return ctn
+	}
+
+	t.Run("Where Called Concurrently With Scope", func(t *testing.T) {
+		scope := newContainer().CreateScope()
+		p := GetService[*testWorkerPool](scope)
+
+		var wg sync.WaitGroup
+		workers := make([]*testService, 20)
+		for i := range workers {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				workers[i] = p.newWorker()
+				_ = scope.GetService("di.testDependency2")
+			}(i)
+		}
+		wg.Wait()
+
+		for _, w := range workers {
+			assert.Same(t, scope.GetService("di.testService"), w)
+		}
+	})
+
+	t.Run("Where Called By Constructor In Scope", func(t *testing.T) {
+		ctn := newContainer()
+		ctn.AddScoped(func(p *testWorkerPool) *testDependency {
+			assert.NotNil(t, p.newWorker())
+			return &testDependency{}
+		})
+		scope := ctn.CreateScope()
+
+		// The pool is built first, so its factory's resolution has completed.
+		_ = GetService[*testWorkerPool](scope)
+		assert.NotNil(t, GetService[*testDependency](scope))
+	})
+}
@@ -0,0 +1,259 @@
+package di
+
+import (
+	"context"
+	"reflect"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// dependency is a constructor parameter of a service, and the
+// services which would be used to resolve it.
+type dependency struct {
+	typ  reflect.Type
+	svcs []*Service
+
+	// Whether the dependency is resolved lazily,
+	// after the service has been built.
+	lazy bool
+
+	// Whether the dependency can be left unresolved.
+	optional bool
+
+	// Whether the dependency is resolved using all of svcs.
+	multi bool
+}
+
+// dependencies returns the dependencies of the service s, including those of
+// its decorators, without building anything. The caller must hold the read lock.
+func (ctn *Container) dependencies(s *Service) []dependency {
+	deps := ctn.paramDependencies(s.constructor(), 0)
+	for _, d := range s.decorators {
+		deps = append(deps, ctn.paramDependencies(d.constructor(), 1)...)
+	}
+
+	return deps
+}
+
+// paramDependencies returns the dependencies for the parameters of the
+// constructor c, skipping the first n parameters.
+func (ctn *Container) paramDependencies(c *constructor, n int) []dependency {
+	deps := make([]dependency, 0, len(c.params))
+	for _, p := range c.params[n:] {
+		if p.in {
+			deps = append(deps, ctn.inDependencies(p.typ)...)
+			continue
+		}
+
+		var dep dependency
+		switch {
+		case p.name != "" && !p.factory && !p.provider && !p.optional:
+			dep = ctn.namedDependency(p.typ, p.name)
+		default:
+			dep = ctn.dependency(p.typ)
+		}
+
+		if p.zero {
+			dep.optional = true
+		}
+		deps = append(deps, dep)
+	}
+
+	return deps
+}
+
+// inDependencies returns the dependencies for the fields of the In struct t.
+func (ctn *Container) inDependencies(t reflect.Type) []dependency {
+	idx, tags := inFields(t)
+	deps := make([]dependency, 0, len(idx))
+	for i, fi := range idx {
+		ft := t.Field(fi).Type
+
+		var dep dependency
+		switch {
+		case tags[i].name != "" && !isFactory(ft) && !isProvider(ft) && !isOptional(ft):
+			dep = ctn.namedDependency(ft, tags[i].name)
+		default:
+			dep = ctn.dependency(ft)
+		}
+
+		if tags[i].optional {
+			dep.optional = true
+		}
+		deps = append(deps, dep)
+	}
+
+	return deps
+}
+
+// namedDependency returns the dependency for a parameter of type t, which is
+// resolved by the name of its service, in this container or its parent.
+func (ctn *Container) namedDependency(t reflect.Type, name string) dependency {
+	dep := dependency{typ: t}
+	if svcs := ctn.byName[name]; len(svcs) > 0 {
+		dep.svcs = svcs[len(svcs)-1:]
+	} else if ctn.parent != nil {
+		if ps := ctn.parent.lookupName(name); ps != nil {
+			dep.svcs = []*Service{ps}
+		}
+	}
+
+	return dep
+}
+
+// dependency returns the dependency for a parameter of type t. Where there
+// are no services for t in the container, those in its parent are used, in
+// the same way as they would be resolved.
+func (ctn *Container) dependency(t reflect.Type) dependency {
+	dep := dependency{typ: t}
+	inherit := false
+
+	switch {
+	case t == contextType:
+		dep.optional = true
+	case (isContainerType(t) || t == scopeType) && len(ctn.byType[t]) == 0:
+		dep.optional = true
+	case isFactory(t):
+		dep = ctn.dependency(t.Out(0))
+		dep.typ = t
+		dep.lazy = true
+	case isProvider(t):
+		dep = ctn.dependency(reflect.New(t).Interface().(providerDependency).provided())
+		dep.typ = t
+		dep.lazy = true
+	case isOptional(t):
+		dep = ctn.dependency(reflect.New(t).Interface().(optionalDependency).optional())
+		dep.typ = t
+		dep.optional = true
+	case isKeyed(t):
+		typ, key := reflect.New(t).Interface().(keyedDependency).keyed()
+		for _, s := range ctn.byType[typ] {
+			if s.key == key {
+				dep.svcs = append(dep.svcs, s)
+				break
+			}
+		}
+		inherit = true
+	case len(ctn.byType[t]) == 0 && t.Kind() == reflect.Slice:
+		dep.svcs = ctn.byType[t.Elem()]
+		dep.optional = true
+		dep.multi = true
+		inherit = true
+	case len(ctn.byType[t]) == 0 && isServiceMap(t):
+		dep.svcs = ctn.assignable(t.Elem())
+		dep.optional = true
+		dep.multi = true
+		inherit = true
+	default:
+		dep.svcs = ctn.byType[t]
+		if p := primaryOf(dep.svcs); len(dep.svcs) > 1 && p != nil {
+			dep.svcs = []*Service{p}
+		}
+		inherit = true
+	}
+
+	if inherit && len(dep.svcs) == 0 && ctn.parent != nil {
+		ctn.parent.mu.RLock()
+		defer ctn.parent.mu.RUnlock()
+
+		return ctn.parent.dependency(t)
+	}
+
+	return dep
+}
+
+// dependencyOrder returns the services in the container ordered such that
+// each service comes after its dependencies. Services in a cycle are ordered
+// arbitrarily, and those of a parent container are left out. The caller must
+// hold the read lock.
+func (ctn *Container) dependencyOrder() []*Service {
+	order := make([]*Service, 0, len(ctn.services))
+	visited := make(map[*Service]bool, len(ctn.services))
+	local := ctn.serviceSet()
+
+	var visit func(s *Service)
+	visit = func(s *Service) {
+		if visited[s] || !local[s] {
+			return
+		}
+		visited[s] = true
+
+		for _, dep := range ctn.dependencies(s) {
+			for _, ds := range dep.svcs {
+				visit(ds)
+			}
+		}
+
+		order = append(order, s)
+	}
+
+	for _, s := range ctn.services {
+		visit(s)
+	}
+
+	return order
+}
+
+// dependencyLevels returns the services grouped by their depth in the
+// dependency graph, where services in the first level have no dependencies
+// and those in each following level only depend on services in previous
+// levels. Services in the same level can therefore be built concurrently.
+//
+// Where there is a cycle, the service found to complete it is placed
+// in a lower level than the service it depends on, so the cycle is
+// detected by a single build, rather than by concurrent builds. Services
+// of a parent container are left out.
+func (ctn *Container) dependencyLevels() [][]*Service {
+	levels := make(map[*Service]int, len(ctn.services))
+	visiting := make(map[*Service]bool)
+	local := ctn.serviceSet()
+
+	var visit func(s *Service) int
+	visit = func(s *Service) int {
+		if !local[s] {
+			return -1
+		}
+		if l, ok := levels[s]; ok {
+			return l
+		}
+		if visiting[s] {
+			return -1
+		}
+		visiting[s] = true
+
+		l := 0
+		for _, dep := range ctn.dependencies(s) {
+			for _, ds := range dep.svcs {
+				if dl := visit(ds) + 1; dl > l {
+					l = dl
+				}
+			}
+		}
+
+		delete(visiting, s)
+		levels[s] = l
+		return l
+	}
+
+	grouped := make([][]*Service, 0)
+	for _, s := range ctn.services {
+		l := visit(s)
+		for len(grouped) <= l {
+			grouped = append(grouped, nil)
+		}
+		grouped[l] = append(grouped[l], s)
+	}
+
+	return grouped
+}
+
+// serviceSet returns the set of services registered in the
+// container. The caller must hold the read lock.
+func (ctn *Container) serviceSet() map[*Service]bool {
+	set := make(map[*Service]bool, len(ctn.services))
+	for _, s := range ctn.services {
+		set[s] = true
+	}
+
+	return set
+}
@@ -0,0 +1,28 @@
+package di
+
+import (
+	"context"
+	"net/http"
+)
+
+// ScopeMiddleware returns HTTP middleware which creates a Scope for each
+// request, using the request's context.Context. The Scope is stored in the
+// request's context, and can be retrieved using ScopeFromRequest, or
+// ScopeFromContext. Once the handler returns, the Scope is disposed.
+func ScopeMiddleware(ctn *Container) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := ctn.CreateScopeWithContext(r.Context())
+			defer scope.Dispose(context.Background())
+
+			next.ServeHTTP(w, r.WithContext(ContextWithScope(r.Context(), scope)))
+		})
+	}
+}
+
+// ScopeFromRequest returns the Scope created for r by ScopeMiddleware,
+// or nil, if the request was not handled by the middleware.
+func ScopeFromRequest(r *http.Request) *Scope {
+	scope, _ := ScopeFromContext(r.Context())
+	return scope
+}
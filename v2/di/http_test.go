@@ -0,0 +1,46 @@
+package di
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeMiddleware(t *testing.T) {
+	type ctxKey struct{}
+
+	var disposed []interface{}
+
+	ctn := NewContainer()
+	ctn.AddService(func(ctx context.Context) *testService {
+		return &testService{x: ctx.Value(ctxKey{}).(int)}
+	}).AsScoped().SetDispose(func(ctx context.Context, i interface{}) {
+		disposed = append(disposed, i)
+	})
+
+	var svc interface{}
+	h := ScopeMiddleware(ctn)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := ScopeFromRequest(r)
+		svc = scope.GetService("di.testService")
+
+		// The service should be re-used within the request.
+		assert.Same(t, svc, scope.GetService("di.testService"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, 42))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	// The service should see the request's context, and be
+	// disposed once the handler returns.
+	assert.Equal(t, 42, svc.(*testService).x)
+	assert.Equal(t, []interface{}{svc}, disposed)
+
+	t.Run("Where request was not handled by the middleware", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.Nil(t, ScopeFromRequest(r))
+	})
+}
@@ -0,0 +1,151 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// In is embedded in a struct used as a constructor parameter, so that each
+// of the struct's exported fields is resolved individually, rather than the
+// struct being resolved as a whole. This is useful for constructors with many
+// dependencies, and allows fields to be configured using a `di` tag:
+//
+//	type HandlerParams struct {
+//		di.In
+//
+//		DB      *sql.DB     `di:"name=primaryDB"`
+//		Metrics MetricsSink `di:"optional"`
+//	}
+//
+//	func NewHandler(p HandlerParams) *Handler
+//
+// A field tagged with name is resolved by the service's name, rather than its
+// type, whereas an optional field is left as its zero value if its service is
+// not registered. Fields tagged with `di:"-"` are ignored.
+type In struct{}
+
+var inType = reflect.TypeOf(In{})
+
+// isIn determines whether the type t is a struct which embeds In.
+func isIn(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == inType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldTag is the parsed `di` tag of a struct field.
+type fieldTag struct {
+	name     string
+	optional bool
+	ignore   bool
+}
+
+// parseFieldTag parses the `di` tag of the field f, which is a comma
+// separated list of options, such as `di:"name=primaryDB,optional"`.
+func parseFieldTag(f reflect.StructField) fieldTag {
+	var tag fieldTag
+	for _, opt := range strings.Split(f.Tag.Get("di"), ",") {
+		switch {
+		case opt == "-":
+			tag.ignore = true
+		case opt == "optional":
+			tag.optional = true
+		case strings.HasPrefix(opt, "name="):
+			tag.name = strings.TrimPrefix(opt, "name=")
+		}
+	}
+
+	return tag
+}
+
+// inFields returns the indexes of the fields of the In struct t
+// which should be resolved, and their parsed tags.
+func inFields(t reflect.Type) ([]int, []fieldTag) {
+	var (
+		idx  []int
+		tags []fieldTag
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || (f.Anonymous && f.Type == inType) {
+			continue
+		}
+
+		tag := parseFieldTag(f)
+		if tag.ignore {
+			continue
+		}
+
+		idx = append(idx, i)
+		tags = append(tags, tag)
+	}
+
+	return idx, tags
+}
+
+// resolveIn is used to resolve an In struct of type t, by resolving
+// each of its fields using r.
+func resolveIn(t reflect.Type, r resolver) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	idx, tags := inFields(t)
+	for i, fi := range idx {
+		f := t.Field(fi)
+		fv, err := resolveField(f.Type, tags[i], r)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("service: failed to resolve field %s of %s, %w", f.Name, t.String(), err)
+		}
+
+		v.Field(fi).Set(fv)
+	}
+
+	return v, nil
+}
+
+// resolveField is used to resolve a value of type t, configured by tag,
+// using r. If the field is optional and its service is not registered,
+// the zero value of t is returned.
+func resolveField(t reflect.Type, tag fieldTag, r resolver) (reflect.Value, error) {
+	if isFactory(t) {
+		return makeFactory(t, r), nil
+	}
+
+	if isProvider(t) {
+		return makeProvider(t, r), nil
+	}
+
+	if isOptional(t) {
+		return resolveOptional(t, r)
+	}
+
+	var (
+		d   interface{}
+		err error
+	)
+
+	if tag.name != "" {
+		d, err = r.resolveNamed(tag.name)
+	} else {
+		d, err = r.Resolve(t)
+	}
+
+	switch {
+	case err != nil && tag.optional && isNotFound(err):
+		return reflect.Zero(t), nil
+	case err != nil:
+		return reflect.Value{}, err
+	case d != nil && !reflect.TypeOf(d).AssignableTo(t):
+		return reflect.Value{}, fmt.Errorf("service: %s is not assignable to %s", reflect.TypeOf(d).String(), t.String())
+	}
+
+	return valueOf(d, t), nil
+}
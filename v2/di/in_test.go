@@ -0,0 +1,87 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testInParams struct {
+	In
+
+	Dep     *testDependency
+	Repo    testRepository   `di:"name=memory"`
+	Missing *testDependency2 `di:"optional"`
+	Ignored *testDependency  `di:"-"`
+}
+
+type testInService struct {
+	params testInParams
+}
+
+func newTestInService(p testInParams) *testInService {
+	return &testInService{params: p}
+}
+
+func TestIn(t *testing.T) {
+	t.Run("Given Named And Optional Fields", func(t *testing.T) {
+		dep := &testDependency{}
+
+		ctn := NewContainer()
+		ctn.AddInstance(dep)
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		}).SetName("sql")
+		ctn.AddService(func() testRepository {
+			return &testMemoryRepository{}
+		}).SetName("memory")
+		s := ctn.AddService(newTestInService)
+
+		v := ctn.GetService(s.Name()).(*testInService)
+		assert.Same(t, dep, v.params.Dep)
+		assert.Equal(t, "memory", v.params.Repo.Get())
+		assert.Nil(t, v.params.Missing)
+		assert.Nil(t, v.params.Ignored)
+		assert.NoError(t, ctn.Validate())
+	})
+
+	t.Run("Where Named Service Is Not Registered", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddInstance(&testDependency{})
+		s := ctn.AddService(newTestInService)
+
+		assert.PanicsWithError(t, "container: failed to build di.testInService, service: failed to resolve field Repo of di.testInParams, container: could not find service, memory", func() {
+			_ = ctn.GetService(s.Name())
+		})
+		assert.Error(t, ctn.Validate())
+	})
+
+	t.Run("Where Named Service Is Not Assignable", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddInstance(&testDependency{})
+		ctn.AddService(func() *testDependency2 {
+			return &testDependency2{}
+		}).SetName("memory")
+		s := ctn.AddService(newTestInService)
+
+		assert.PanicsWithError(t, "container: failed to build di.testInService, service: failed to resolve field Repo of di.testInParams, service: *di.testDependency2 is not assignable to di.testRepository", func() {
+			_ = ctn.GetService(s.Name())
+		})
+	})
+
+	t.Run("Given Scope", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddScoped(func() testRepository {
+			return &testMemoryRepository{}
+		}).SetName("memory")
+		s := ctn.AddScoped(newTestInService)
+
+		scope := ctn.CreateScope()
+		v := scope.GetService(s.Name()).(*testInService)
+		assert.Same(t, scope.GetService("di.testDependency"), v.params.Dep)
+		assert.Same(t, scope.GetService("memory"), v.params.Repo)
+	})
+}
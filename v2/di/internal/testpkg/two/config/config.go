@@ -0,0 +1,8 @@
+// Package config is used to test resolving types with the same name,
+// from different packages, as package one/config.
+package config
+
+// Config is a type with the same name as one/config.Config.
+type Config struct {
+	Name string
+}
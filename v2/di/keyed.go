@@ -0,0 +1,47 @@
+package di
+
+import "reflect"
+
+// Keyer is implemented by types used to specify the key of a keyed
+// dependency. A Keyer should be a zero-sized type, for example:
+//
+//	type cacheKey struct{}
+//
+//	func (cacheKey) Key() string { return "cache" }
+type Keyer interface {
+	Key() string
+}
+
+// Keyed is used as a constructor parameter to depend on a service of type T,
+// which has been registered with the key given by K, using Service.WithKey.
+// This allows multiple services of the same type to be injected by type:
+//
+//	func NewStore(c di.Keyed[*redis.Client, cacheKey]) *Store
+type Keyed[T any, K Keyer] struct {
+	Value T
+}
+
+// keyedDependency is implemented by *Keyed, and is used to
+// detect and populate keyed dependencies during a build.
+type keyedDependency interface {
+	keyed() (reflect.Type, string)
+	set(v interface{})
+}
+
+var keyedDependencyType = reflect.TypeOf((*keyedDependency)(nil)).Elem()
+
+func (Keyed[T, K]) keyed() (reflect.Type, string) {
+	var k K
+	return reflect.TypeOf((*T)(nil)).Elem(), k.Key()
+}
+
+func (k *Keyed[T, K]) set(v interface{}) {
+	if v != nil {
+		k.Value = v.(T)
+	}
+}
+
+// isKeyed determines whether the type t is a Keyed dependency.
+func isKeyed(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(keyedDependencyType)
+}
@@ -0,0 +1,109 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCacheKey struct{}
+
+func (testCacheKey) Key() string { return "cache" }
+
+type testSessionKey struct{}
+
+func (testSessionKey) Key() string { return "session" }
+
+type testKeyedService struct {
+	cache   *testService
+	session *testService
+}
+
+func TestKeyed(t *testing.T) {
+	t.Run("Where Keyed Services Exist", func(t *testing.T) {
+		cache := &testService{x: 1}
+		session := &testService{x: 2}
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testService { return cache }).WithKey("cache")
+		ctn.AddService(func() *testService { return session }).WithKey("session")
+		ctn.AddService(func(c Keyed[*testService, testCacheKey], s Keyed[*testService, testSessionKey]) *testKeyedService {
+			return &testKeyedService{cache: c.Value, session: s.Value}
+		})
+
+		v := GetService[*testKeyedService](ctn)
+		assert.Same(t, cache, v.cache)
+		assert.Same(t, session, v.session)
+	})
+
+	t.Run("Where Keyed Service Does Not Exist", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService { return &testService{} })
+		ctn.AddService(func(c Keyed[*testService, testCacheKey]) *testKeyedService {
+			return &testKeyedService{cache: c.Value}
+		})
+
+		assert.Panics(t, func() {
+			_ = GetService[*testKeyedService](ctn)
+		})
+	})
+}
+
+func TestGetKeyedService(t *testing.T) {
+	newContainer := func() *Container {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService { return &testService{x: 1} }).WithKey("cache")
+		ctn.AddService(func() *testService { return &testService{x: 2} }).WithKey("session").AsScoped()
+		return ctn
+	}
+
+	t.Run("Given Container", func(t *testing.T) {
+		ctn := newContainer()
+
+		v := GetKeyedService[*testService](ctn, "cache")
+		assert.Equal(t, 1, v.x)
+
+		assert.PanicsWithError(t, "container: failed to resolve *di.testService with key other", func() {
+			_ = GetKeyedService[*testService](ctn, "other")
+		})
+	})
+
+	t.Run("Given Scope", func(t *testing.T) {
+		scope := newContainer().CreateScope()
+
+		assert.Equal(t, 1, GetKeyedService[*testService](scope, "cache").x)
+
+		v := GetKeyedService[*testService](scope, "session")
+		assert.Equal(t, 2, v.x)
+		assert.Same(t, v, GetKeyedService[*testService](scope, "session"))
+	})
+
+	t.Run("Given Keyed Dependency In Scope", func(t *testing.T) {
+		ctn := newContainer()
+		ctn.AddScoped(func(c Keyed[*testService, testCacheKey], s Keyed[*testService, testSessionKey]) *testKeyedService {
+			return &testKeyedService{cache: c.Value, session: s.Value}
+		})
+		scope := ctn.CreateScope()
+
+		v := GetService[*testKeyedService](scope)
+		assert.Equal(t, 1, v.cache.x)
+		assert.Same(t, GetKeyedService[*testService](scope, "session"), v.session)
+	})
+}
+
+func TestGetKeyedService_ScopedServicesOfSameType(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddScoped(func() *testService { return &testService{x: 1} }).WithKey("a").SetName("A")
+	ctn.AddScoped(func() *testService { return &testService{x: 2} }).WithKey("b").SetName("B")
+	scope := ctn.CreateScope()
+
+	a := GetKeyedService[*testService](scope, "a")
+	b := GetKeyedService[*testService](scope, "b")
+	assert.Equal(t, 1, a.x)
+	assert.Equal(t, 2, b.x)
+	assert.NotSame(t, a, b)
+
+	assert.Same(t, a, scope.GetService("A"))
+	assert.Same(t, b, scope.GetService("B"))
+	assert.Same(t, b, GetKeyedService[*testService](scope, "b"))
+}
@@ -0,0 +1,62 @@
+package di
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Lazy is used to defer the resolution of a service of type T until
+// it is first needed. This is helpful to break initialization order
+// problems, or to avoid building services which may never be used.
+type Lazy[T any] struct {
+	mu    sync.Mutex
+	sp    ServiceProvider
+	done  bool
+	value T
+}
+
+// NewLazy returns a new Lazy, which resolves T from the given ServiceProvider.
+func NewLazy[T any](sp ServiceProvider) *Lazy[T] {
+	return &Lazy[T]{sp: sp}
+}
+
+// Value returns the service, resolving it on the first call. Once resolved,
+// the service is cached and returned on subsequent calls. If the service fails
+// to resolve, an error is returned and the next call will try again.
+//
+// Value is safe to be called concurrently.
+func (l *Lazy[T]) Value() (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return l.value, nil
+	}
+
+	v, err := l.resolve()
+	if err != nil {
+		return v, err
+	}
+
+	l.value = v
+	l.done = true
+
+	return v, nil
+}
+
+// resolve is used to resolve T from the ServiceProvider,
+// recovering the panic raised when it fails.
+func (l *Lazy[T]) resolve() (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+				return
+			}
+
+			err = fmt.Errorf("container: %v", r)
+		}
+	}()
+
+	return GetService[T](l.sp), nil
+}
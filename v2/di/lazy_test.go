@@ -0,0 +1,67 @@
+package di
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazy_Value(t *testing.T) {
+	t.Run("Where Service Exists", func(t *testing.T) {
+		builds := 0
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			builds++
+			return &testService{}
+		})
+
+		l := NewLazy[*testService](ctn)
+		assert.Equal(t, 0, builds)
+
+		var wg sync.WaitGroup
+		values := make([]*testService, 10)
+		for i := range values {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				v, err := l.Value()
+				assert.Nil(t, err)
+				values[i] = v
+			}(i)
+		}
+		wg.Wait()
+
+		// The service should only be resolved once.
+		assert.Equal(t, 1, builds)
+		for _, v := range values {
+			assert.Same(t, values[0], v)
+		}
+	})
+
+	t.Run("Where Service Fails To Build", func(t *testing.T) {
+		fail := true
+
+		ctn := NewContainer()
+		ctn.AddService(func() (*testService, error) {
+			if fail {
+				return nil, assert.AnError
+			}
+			return &testService{}, nil
+		})
+
+		l := NewLazy[*testService](ctn)
+
+		v, err := l.Value()
+		assert.Nil(t, v)
+		assert.Contains(t, err.Error(), assert.AnError.Error())
+
+		// Should retry after an error.
+		fail = false
+		v, err = l.Value()
+		assert.NotNil(t, v)
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// OnStart is used to configure a function called when the Container is
+// started, using Container.Start. This can be used for work which should
+// only begin once the application is wired, such as consuming a queue.
+func (s *Service) OnStart(f func(ctx context.Context) error) *Service {
+	s.onStart = f
+
+	return s
+}
+
+// OnStop is used to configure a function called when the Container
+// is stopped, using Container.Stop, if the service was started.
+func (s *Service) OnStop(f func(ctx context.Context) error) *Service {
+	s.onStop = f
+
+	return s
+}
+
+// Start is used to build each service with an OnStart or OnStop function, then
+// call its OnStart function, in order of their dependencies, so services are
+// started after the services they depend on. Scoped services are not built.
+// Services are built with ctx, in the same way as WarmUp, so constructors can
+// observe its cancellation.
+//
+// If a service fails to build or start, the services already started are
+// stopped, in the same way as Stop, and the errors are joined and returned.
+func (ctn *Container) Start(ctx context.Context) error {
+	ctn.mu.RLock()
+	order := ctn.dependencyOrder()
+	ctn.mu.RUnlock()
+
+	var started []*Service
+	for _, s := range order {
+		if s.onStart == nil && s.onStop == nil {
+			continue
+		}
+
+		if err := ctn.start(ctx, s); err != nil {
+			return errors.Join(err, stop(ctx, started))
+		}
+
+		started = append(started, s)
+	}
+
+	ctn.mu.Lock()
+	ctn.started = append(ctn.started, started...)
+	ctn.mu.Unlock()
+
+	return nil
+}
+
+// start is used to build the service s, if it isn't scoped,
+// then call its OnStart function.
+func (ctn *Container) start(ctx context.Context, s *Service) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.lifetime != LifetimeScoped {
+		if _, err := ctn.build(ctx, nil, s); err != nil {
+			return err
+		}
+	}
+
+	if s.onStart == nil {
+		return nil
+	}
+
+	if err := s.onStart(ctx); err != nil {
+		return fmt.Errorf("container: failed to start %s, %w", s.Name(), err)
+	}
+
+	return nil
+}
+
+// Stop is used to call the OnStop function of each service started by Start,
+// in the reverse order they were started, so services are stopped before the
+// services they depend on. The errors returned are joined and returned.
+func (ctn *Container) Stop(ctx context.Context) error {
+	ctn.mu.Lock()
+	started := ctn.started
+	ctn.started = nil
+	ctn.mu.Unlock()
+
+	return stop(ctx, started)
+}
+
+// stop is used to call the OnStop function of each service
+// in started, in reverse order.
+func stop(ctx context.Context, started []*Service) error {
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		s := started[i]
+		if s.onStop == nil {
+			continue
+		}
+
+		if err := s.onStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("container: failed to stop %s, %w", s.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,118 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_Start(t *testing.T) {
+	t.Run("Given Dependent Services", func(t *testing.T) {
+		var order []string
+		hook := func(event string) func(ctx context.Context) error {
+			return func(ctx context.Context) error {
+				order = append(order, event)
+				return nil
+			}
+		}
+
+		ctn := NewContainer()
+		ctn.AddSingleton(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		}).OnStart(hook("start service")).OnStop(hook("stop service"))
+		ctn.AddSingleton(func() *testDependency {
+			order = append(order, "build dependency")
+			return &testDependency{}
+		}).OnStart(hook("start dependency")).OnStop(hook("stop dependency"))
+
+		assert.NoError(t, ctn.Start(context.Background()))
+		assert.Equal(t, []string{"build dependency", "start dependency", "start service"}, order)
+
+		order = nil
+		assert.NoError(t, ctn.Stop(context.Background()))
+		assert.Equal(t, []string{"stop service", "stop dependency"}, order)
+
+		// Should not stop services again.
+		order = nil
+		assert.NoError(t, ctn.Stop(context.Background()))
+		assert.Empty(t, order)
+	})
+
+	t.Run("Given Child Container", func(t *testing.T) {
+		var order []string
+		hook := func(event string) func(ctx context.Context) error {
+			return func(ctx context.Context) error {
+				order = append(order, event)
+				return nil
+			}
+		}
+
+		parent := NewContainer()
+		parent.AddSingleton(func() *testDependency {
+			return &testDependency{}
+		}).OnStart(hook("start dependency"))
+
+		child := parent.CreateChild()
+		child.AddSingleton(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		}).OnStart(hook("start service"))
+
+		// Services of the parent should only be started by the parent.
+		assert.NoError(t, child.Start(context.Background()))
+		assert.Equal(t, []string{"start service"}, order)
+	})
+
+	t.Run("Given Context", func(t *testing.T) {
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "start")
+
+		ctn := NewContainer()
+		ctn.AddSingleton(func(c context.Context) *testDependency {
+			assert.Equal(t, "start", c.Value(ctxKey{}))
+			return &testDependency{}
+		}).OnStart(func(ctx context.Context) error { return nil })
+
+		assert.NoError(t, ctn.Start(ctx))
+	})
+
+	t.Run("Where Service Fails To Start", func(t *testing.T) {
+		testErr := errors.New("test error")
+		stopped := false
+
+		ctn := NewContainer()
+		ctn.AddSingleton(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		}).OnStart(func(ctx context.Context) error {
+			return testErr
+		})
+		ctn.AddSingleton(func() *testDependency {
+			return &testDependency{}
+		}).OnStop(func(ctx context.Context) error {
+			stopped = true
+			return nil
+		})
+
+		err := ctn.Start(context.Background())
+		assert.ErrorIs(t, err, testErr)
+		assert.Contains(t, err.Error(), "container: failed to start di.testService")
+		assert.True(t, stopped)
+	})
+
+	t.Run("Where Service Fails To Stop", func(t *testing.T) {
+		testErr := errors.New("test error")
+
+		ctn := NewContainer()
+		ctn.AddSingleton(func() *testDependency {
+			return &testDependency{}
+		}).OnStop(func(ctx context.Context) error {
+			return testErr
+		})
+
+		assert.NoError(t, ctn.Start(context.Background()))
+		err := ctn.Stop(context.Background())
+		assert.ErrorIs(t, err, testErr)
+		assert.EqualError(t, err, "container: failed to stop di.testDependency, test error")
+	})
+}
@@ -0,0 +1,41 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// isServiceMap determines whether the type t is a map keyed by a string,
+// which can be injected with services keyed by their names:
+// map[string]Handler.
+func isServiceMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// buildServiceMap is used to create a map of type t, where each of the
+// services in svcs is built using build, and keyed by its name. If more
+// than one of the services has the same name, an error is returned,
+// without building any of them.
+func buildServiceMap(t reflect.Type, svcs []*Service, build func(s *Service) (interface{}, error)) (interface{}, error) {
+	names := make(map[string]bool, len(svcs))
+	for _, s := range svcs {
+		if names[s.Name()] {
+			return nil, fmt.Errorf("%w: %s has multiple services named %s", ErrAmbiguousDependency, t.String(), s.Name())
+		}
+		names[s.Name()] = true
+	}
+
+	et := t.Elem()
+	m := reflect.MakeMapWithSize(t, len(svcs))
+	for _, s := range svcs {
+		v, err := build(s)
+		if err != nil {
+			return nil, err
+		}
+
+		key := reflect.ValueOf(s.Name()).Convert(t.Key())
+		m.SetMapIndex(key, valueOf(v, et))
+	}
+
+	return m.Interface(), nil
+}
@@ -0,0 +1,82 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStageRouter struct {
+	stages map[string]testMiddleware
+}
+
+// testCompositeStage is a testMiddleware which depends on every other.
+type testCompositeStage struct {
+	stages map[string]testMiddleware
+}
+
+func (s *testCompositeStage) Name() string { return "composite" }
+
+func TestContainer_ServiceMap(t *testing.T) {
+	newContainer := func() *Container {
+		ctn := NewContainer()
+		ctn.AddInstance(testStage("first")).SetName("first")
+		ctn.AddInstance(testStage("second")).SetName("second")
+		ctn.AddInstance(testStage("third")).SetName("third")
+		ctn.AddService(func(m map[string]testMiddleware) *testStageRouter {
+			return &testStageRouter{stages: m}
+		})
+		return ctn
+	}
+
+	t.Run("Given Named Services", func(t *testing.T) {
+		ctn := newContainer()
+
+		r := GetService[*testStageRouter](ctn)
+		assert.Len(t, r.stages, 3)
+		for _, name := range []string{"first", "second", "third"} {
+			assert.Equal(t, name, r.stages[name].Name())
+		}
+	})
+
+	t.Run("Given Named Services In Scope", func(t *testing.T) {
+		ctn := newContainer()
+
+		r := GetService[*testStageRouter](ctn.CreateScope())
+		assert.Len(t, r.stages, 3)
+	})
+
+	t.Run("Where Names Collide", func(t *testing.T) {
+		ctn := newContainer()
+		ctn.AddInstance(testStage("other")).SetName("di.testStage")
+		ctn.AddInstance(testStage("another")).SetName("di.testStage")
+
+		_, err := ctn.TryGetService("di.testStageRouter")
+		assert.ErrorIs(t, err, ErrAmbiguousDependency)
+		assert.Contains(t, err.Error(), "map[string]di.testMiddleware has multiple services named di.testStage")
+	})
+
+	t.Run("Given Composite Service", func(t *testing.T) {
+		ctn := newContainer()
+		ctn.AddService(func(m map[string]testMiddleware) *testCompositeStage {
+			return &testCompositeStage{stages: m}
+		})
+
+		s := GetService[*testCompositeStage](ctn)
+		assert.Len(t, s.stages, 3)
+		assert.NotContains(t, s.stages, "di.testCompositeStage")
+
+		s = GetService[*testCompositeStage](ctn.CreateScope())
+		assert.Len(t, s.stages, 3)
+	})
+
+	t.Run("Where No Services Are Assignable", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(m map[string]testMiddleware) *testStageRouter {
+			return &testStageRouter{stages: m}
+		})
+
+		r := GetService[*testStageRouter](ctn)
+		assert.Empty(t, r.stages)
+	})
+}
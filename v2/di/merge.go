@@ -0,0 +1,151 @@
+package di
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictPolicy determines how Container.Merge handles a service with
+// the same name as a service already registered in the container.
+type ConflictPolicy int
+
+const (
+	// ConflictSkip keeps the service already registered,
+	// and the conflicting service is not merged.
+	ConflictSkip ConflictPolicy = iota
+
+	// ConflictOverwrite removes the services already registered
+	// with the name, and the conflicting service is merged.
+	ConflictOverwrite
+
+	// ConflictError causes Merge to return an error, without
+	// merging any of the services.
+	ConflictError
+)
+
+// Merge is used to add the services registered in other to the container,
+// in their registration order, such as to compose a container from those
+// configured by libraries. Where a service has the same name as one already
+// registered, onConflict determines whether it is merged.
+//
+// The services are copied, so other is not changed and can still be used.
+// Singletons already built in other are shared by both containers, where
+// they are only disposed by other, whereas those which have not been built
+// are built independently by each. Merge panics if the container is frozen.
+func (ctn *Container) Merge(other *Container, onConflict ConflictPolicy) error {
+	if other == ctn {
+		return nil
+	}
+
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("merge another container")
+
+	other.mu.RLock()
+	svcs := make([]*Service, len(other.services))
+	copy(svcs, other.services)
+	other.mu.RUnlock()
+
+	var conflicts []string
+	conflicted := make(map[*Service]bool)
+	for _, s := range svcs {
+		if len(ctn.byName[s.Name()]) > 0 {
+			conflicts = append(conflicts, s.Name())
+			conflicted[s] = true
+		}
+	}
+
+	if len(conflicts) > 0 {
+		switch onConflict {
+		case ConflictError:
+			return fmt.Errorf("%w, %s", ErrNameConflict, strings.Join(conflicts, ", "))
+		case ConflictOverwrite:
+			ctn.removeNamed(conflicts)
+		}
+	}
+
+	for _, s := range svcs {
+		if onConflict == ConflictSkip && conflicted[s] {
+			continue
+		}
+
+		c := s.clone()
+		c.ctn = ctn
+		if sg := s.inst.Load(); sg != nil && sg.built.Load() {
+			b := newSingleton(sg.impl)
+			b.borrowed = true
+			c.inst.Store(b)
+		}
+		ctn.services = append(ctn.services, c)
+		ctn.index(c)
+	}
+
+	return nil
+}
+
+// removeNamed is used to remove the services with any of the given names
+// from the container. The caller must hold the write lock.
+func (ctn *Container) removeNamed(names []string) {
+	remove := make(map[string]bool, len(names))
+	for _, n := range names {
+		remove[n] = true
+	}
+
+	svcs := make([]*Service, 0, len(ctn.services))
+	for _, s := range ctn.services {
+		if remove[s.name] {
+			s.ctn = nil
+			continue
+		}
+		svcs = append(svcs, s)
+	}
+
+	ctn.services = svcs
+	ctn.rebuildIndex()
+}
+
+// clone returns a copy of the service's configuration, without its singleton
+// instance. The copy doesn't belong to a container, and its Out services are
+// not copied, as they are registered separately.
+func (s *Service) clone() *Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := &Service{
+		name:          s.name,
+		typ:           s.typ,
+		lifetime:      s.lifetime,
+		ctor:          s.ctor,
+		dipsose:       s.dipsose,
+		disposeE:      s.disposeE,
+		groups:        append([]string(nil), s.groups...),
+		key:           s.key,
+		invoker:       s.invoker,
+		decorators:    append([]*Service(nil), s.decorators...),
+		scopedBy:      s.scopedBy,
+		ifaces:        append(s.ifaces[:0:0], s.ifaces...),
+		promoteAfter:  s.promoteAfter,
+		primary:       s.primary,
+		aliases:       append([]string(nil), s.aliases...),
+		retryAttempts: s.retryAttempts,
+		retryBackoff:  s.retryBackoff,
+		onStart:       s.onStart,
+		onStop:        s.onStop,
+		order:         s.order,
+		timeout:       s.timeout,
+		value:         s.value,
+	}
+	if sig := s.sig.Load(); sig != nil {
+		c.sig.Store(sig)
+	}
+
+	if s.meta != nil {
+		c.meta = make(map[string]interface{}, len(s.meta))
+		for k, v := range s.meta {
+			c.meta[k] = v
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,83 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_Merge(t *testing.T) {
+	newContainers := func() (*Container, *Container) {
+		ctn := NewContainer()
+		ctn.AddNamedValue("region", "eu-west-2")
+		ctn.AddSingleton(func() *testDependency {
+			return &testDependency{}
+		})
+
+		other := NewContainer()
+		other.AddNamedValue("region", "us-east-1")
+		other.AddSingleton(func() *testService {
+			return &testService{x: 1}
+		})
+		return ctn, other
+	}
+
+	t.Run("Given Skip Policy", func(t *testing.T) {
+		ctn, other := newContainers()
+
+		err := ctn.Merge(other, ConflictSkip)
+		assert.NoError(t, err)
+		assert.Equal(t, "eu-west-2", ctn.GetService("region"))
+		assert.Equal(t, 1, GetService[*testService](ctn).x)
+		assert.Len(t, ctn.services, 3)
+	})
+
+	t.Run("Given Overwrite Policy", func(t *testing.T) {
+		ctn, other := newContainers()
+
+		err := ctn.Merge(other, ConflictOverwrite)
+		assert.NoError(t, err)
+		assert.Equal(t, "us-east-1", ctn.GetService("region"))
+		assert.Equal(t, "us-east-1", GetService[string](ctn))
+		assert.Len(t, ctn.services, 3)
+	})
+
+	t.Run("Given Error Policy", func(t *testing.T) {
+		ctn, other := newContainers()
+
+		err := ctn.Merge(other, ConflictError)
+		assert.ErrorIs(t, err, ErrNameConflict)
+		assert.EqualError(t, err, "container: service name conflict, region")
+
+		// Nothing should be merged.
+		assert.Len(t, ctn.services, 2)
+		assert.Equal(t, "eu-west-2", ctn.GetService("region"))
+	})
+
+	t.Run("Where Singleton Is Built", func(t *testing.T) {
+		ctn, other := newContainers()
+		v := GetService[*testService](other)
+
+		assert.NoError(t, ctn.Merge(other, ConflictSkip))
+		assert.Same(t, v, GetService[*testService](ctn))
+
+		// The other container should be unchanged.
+		assert.Len(t, other.services, 2)
+		assert.Same(t, other, other.services[1].ctn)
+	})
+
+	t.Run("Where Merged Singleton Is Cleaned", func(t *testing.T) {
+		ctn, other := newContainers()
+		disposed := 0
+		other.services[1].SetDispose(func(context.Context, interface{}) { disposed++ })
+		_ = GetService[*testService](other)
+
+		assert.NoError(t, ctn.Merge(other, ConflictSkip))
+		assert.NoError(t, ctn.Clean(context.Background()))
+		assert.NoError(t, other.Clean(context.Background()))
+
+		// Only other, which built the instance, should dispose it.
+		assert.Equal(t, 1, disposed)
+	})
+}
@@ -0,0 +1,58 @@
+package di
+
+// SetMeta is used to attach a metadata value to the service, such as its
+// version or owner, which can be read using Meta, or queried using
+// Container.FindByMeta. Metadata is not used to resolve the service.
+// Setting a key again replaces its value.
+func (s *Service) SetMeta(key string, value interface{}) *Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.meta == nil {
+		s.meta = make(map[string]interface{})
+	}
+	s.meta[key] = value
+
+	return s
+}
+
+// Meta returns the metadata value set for the given key, using SetMeta,
+// and whether it has been set.
+func (s *Service) Meta(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.meta[key]
+	return v, ok
+}
+
+// metadata returns a copy of the service's metadata.
+func (s *Service) metadata() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := make(map[string]interface{}, len(s.meta))
+	for k, v := range s.meta {
+		m[k] = v
+	}
+	return m
+}
+
+// FindByMeta is used to find the services, in registration order, for which
+// pred returns true, given the service's metadata. Services without metadata
+// are given an empty map. Each map is a copy, so can be retained by pred.
+func (ctn *Container) FindByMeta(pred func(meta map[string]interface{}) bool) []*Service {
+	ctn.mu.RLock()
+	svcs := make([]*Service, len(ctn.services))
+	copy(svcs, ctn.services)
+	ctn.mu.RUnlock()
+
+	found := make([]*Service, 0)
+	for _, s := range svcs {
+		if pred(s.metadata()) {
+			found = append(found, s)
+		}
+	}
+
+	return found
+}
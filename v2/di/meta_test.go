@@ -0,0 +1,48 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_SetMeta(t *testing.T) {
+	s := &Service{}
+	s.SetMeta("owner", "payments").SetMeta("version", 1).SetMeta("version", 2)
+
+	v, ok := s.Meta("owner")
+	assert.True(t, ok)
+	assert.Equal(t, "payments", v)
+
+	v, ok = s.Meta("version")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	_, ok = s.Meta("region")
+	assert.False(t, ok)
+}
+
+func TestContainer_FindByMeta(t *testing.T) {
+	ctn := NewContainer()
+	dep := ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).SetMeta("owner", "payments")
+	ctn.AddService(func() *testDependency2 {
+		return &testDependency2{}
+	}).SetMeta("owner", "search")
+	svc := ctn.AddService(func() *testService {
+		return &testService{}
+	}).SetMeta("owner", "payments").SetMeta("version", "1.2.0")
+	ctn.AddInstance(testStage("untagged"))
+
+	found := ctn.FindByMeta(func(meta map[string]interface{}) bool {
+		return meta["owner"] == "payments"
+	})
+	assert.Equal(t, []*Service{dep, svc}, found)
+
+	found = ctn.FindByMeta(func(meta map[string]interface{}) bool {
+		return len(meta) == 0
+	})
+	assert.Len(t, found, 1)
+	assert.Equal(t, "di.testStage", found[0].Name())
+}
@@ -0,0 +1,26 @@
+package di
+
+// Module is used to bundle related registrations, so they can be added to a
+// Container together. This allows libraries to provide their own services,
+// which applications add using Container.AddModule.
+type Module interface {
+	// Register is used to add the module's services to ctn. This can
+	// register, decorate and alias services, as well as add other modules.
+	Register(ctn *Container)
+}
+
+// ModuleFunc is an adapter to allow a function to be used as a Module.
+type ModuleFunc func(ctn *Container)
+
+// Register calls f(ctn).
+func (f ModuleFunc) Register(ctn *Container) {
+	f(ctn)
+}
+
+// AddModule is used to add the services of each module to the container,
+// by calling their Register methods, in the order they are given.
+func (ctn *Container) AddModule(modules ...Module) {
+	for _, m := range modules {
+		m.Register(ctn)
+	}
+}
@@ -0,0 +1,51 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLoggingModule is an example Module, which registers a logger and
+// a repository, then decorates and aliases them.
+type testLoggingModule struct {
+	prefix string
+}
+
+func (m testLoggingModule) Register(ctn *Container) {
+	Register[testLogger](ctn, func() testBaseLogger { return testBaseLogger{} }).AsSingleton()
+	Register[testRepository](ctn, func() *testSQLRepository { return &testSQLRepository{} }).AddAlias("repo")
+
+	ctn.Decorate("di.testLogger", func(l testLogger) *testPrefixLogger {
+		return &testPrefixLogger{prefix: m.prefix, inner: l}
+	})
+}
+
+func TestContainer_AddModule(t *testing.T) {
+	t.Run("Given Module", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddModule(testLoggingModule{prefix: "module:"})
+
+		assert.Equal(t, "module:log", GetService[testLogger](ctn).Log())
+		assert.Equal(t, "sql", GetService[testRepository](ctn).Get())
+		assert.Equal(t, "sql", ctn.GetService("repo").(testRepository).Get())
+	})
+
+	t.Run("Given ModuleFunc", func(t *testing.T) {
+		var order []string
+
+		ctn := NewContainer()
+		ctn.AddModule(
+			ModuleFunc(func(ctn *Container) {
+				order = append(order, "first")
+				ctn.AddService(func() *testDependency { return &testDependency{} })
+			}),
+			ModuleFunc(func(ctn *Container) {
+				order = append(order, "second")
+			}),
+		)
+
+		assert.Equal(t, []string{"first", "second"}, order)
+		assert.NotNil(t, GetService[*testDependency](ctn))
+	})
+}
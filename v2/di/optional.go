@@ -0,0 +1,64 @@
+package di
+
+import "reflect"
+
+// Optional is used as a constructor parameter to depend on a service of
+// type T, which may not be registered. If there is no service of type T,
+// an empty Optional is injected, rather than the build failing.
+//
+//	func NewHandler(m di.Optional[MetricsSink]) *Handler
+type Optional[T any] struct {
+	value T
+	ok    bool
+}
+
+// Get returns the service and true, if it was resolved,
+// otherwise the zero value of T and false.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// optionalDependency is implemented by *Optional, and is used to
+// detect and populate optional dependencies during a build.
+type optionalDependency interface {
+	optional() reflect.Type
+	set(v interface{})
+}
+
+var optionalDependencyType = reflect.TypeOf((*optionalDependency)(nil)).Elem()
+
+func (Optional[T]) optional() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (o *Optional[T]) set(v interface{}) {
+	if v != nil {
+		o.value = v.(T)
+	}
+	o.ok = true
+}
+
+// isOptional determines whether the type t is an Optional dependency.
+func isOptional(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(optionalDependencyType)
+}
+
+// resolveOptional is used to resolve an Optional dependency of type t, using r.
+// If the underlying service is not registered, an empty Optional is returned,
+// however, if it fails to build, the error is returned.
+func resolveOptional(t reflect.Type, r resolver) (reflect.Value, error) {
+	ov := reflect.New(t)
+	od := ov.Interface().(optionalDependency)
+
+	v, err := r.Resolve(od.optional())
+	if err != nil {
+		if isNotFound(err) {
+			return ov.Elem(), nil
+		}
+
+		return reflect.Value{}, err
+	}
+
+	od.set(v)
+	return ov.Elem(), nil
+}
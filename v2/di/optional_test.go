@@ -0,0 +1,52 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOptionalService struct {
+	dep *testDependency
+	ok  bool
+}
+
+func TestOptional(t *testing.T) {
+	ctor := func(o Optional[*testDependency]) *testOptionalService {
+		dep, ok := o.Get()
+		return &testOptionalService{dep: dep, ok: ok}
+	}
+
+	t.Run("Where Dependency Is Registered", func(t *testing.T) {
+		dep := &testDependency{}
+
+		ctn := NewContainer()
+		ctn.AddInstance(dep)
+		ctn.AddService(ctor)
+
+		v := GetService[*testOptionalService](ctn)
+		assert.True(t, v.ok)
+		assert.Same(t, dep, v.dep)
+	})
+
+	t.Run("Where Dependency Is Not Registered", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(ctor)
+
+		v := GetService[*testOptionalService](ctn)
+		assert.False(t, v.ok)
+		assert.Nil(t, v.dep)
+	})
+
+	t.Run("Where Dependency Fails To Build", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() (*testDependency, error) {
+			return nil, assert.AnError
+		})
+		ctn.AddService(ctor)
+
+		assert.Panics(t, func() {
+			_ = GetService[*testOptionalService](ctn)
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package di
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Option is used to configure a Container, when it is created.
+type Option func(ctn *Container)
+
+// WithContext configures the base context.Context of a Container, which is
+// used to satisfy context.Context parameters of services resolved outside
+// of a Scope, and is the context of scopes created using CreateScope.
+// By default, context.Background() is used.
+func WithContext(ctx context.Context) Option {
+	return func(ctn *Container) {
+		ctn.ctx = ctx
+	}
+}
+
+// WithLogger configures a Container to log each time a service is built,
+// at debug level, with the service's name, lifetime and build duration.
+// Singletons resolved after they are built are not logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(ctn *Container) {
+		ctn.logger = logger
+	}
+}
+
+// WithStrictNames configures a Container to enforce unique service names,
+// in the same way as SetStrict.
+func WithStrictNames() Option {
+	return func(ctn *Container) {
+		ctn.strict = true
+	}
+}
+
+// WithRecoverPanics configures whether a Container recovers panics raised
+// by constructors, returning them as build errors, in the same way as
+// SetRecoverPanics. By default, panics are recovered.
+func WithRecoverPanics(enabled bool) Option {
+	return func(ctn *Container) {
+		ctn.noRecover = !enabled
+	}
+}
+
+// WithMaxDepth configures a Container to return ErrMaxDepthExceeded, rather
+// than continuing to build services, once a chain of dependencies is more than
+// n levels deep. This protects against pathological, or generated, dependency
+// graphs exhausting the stack. By default, or if n is not positive, there is
+// no maximum depth.
+func WithMaxDepth(n int) Option {
+	return func(ctn *Container) {
+		ctn.maxDepth = n
+	}
+}
@@ -0,0 +1,41 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContainer_Options(t *testing.T) {
+	t.Run("Where No Options Are Given", func(t *testing.T) {
+		ctn := NewContainer()
+		assert.False(t, ctn.strict)
+		assert.False(t, ctn.noRecover)
+		assert.Nil(t, ctn.logger)
+	})
+
+	t.Run("Where Strict Names Are Enabled", func(t *testing.T) {
+		ctn := NewContainer(WithStrictNames())
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		})
+		s := ctn.AddService(func() *testService {
+			return &testService{}
+		})
+
+		assert.Panics(t, func() {
+			s.SetName("di.testDependency")
+		})
+	})
+
+	t.Run("Where Panics Are Not Recovered", func(t *testing.T) {
+		ctn := NewContainer(WithRecoverPanics(false))
+		ctn.AddService(func() *testService {
+			panic("test panic")
+		})
+
+		assert.PanicsWithValue(t, "test panic", func() {
+			_ = ctn.GetService("di.testService")
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package di
+
+import "reflect"
+
+// Out is embedded in a struct returned by a constructor, so that each of
+// the struct's exported fields is provided as a service, which can be
+// resolved by its type, in addition to the struct itself:
+//
+//	type Repositories struct {
+//		di.Out
+//
+//		Users  UserRepository
+//		Orders OrderRepository `di:"name=orders"`
+//	}
+//
+//	func NewRepositories(db *sql.DB) Repositories
+//
+// A field tagged with name is given that name, rather than the name of its
+// type. Fields tagged with `di:"-"` are ignored.
+//
+// The field services share the lifetime of the struct's service, which is a
+// Singleton by default, so the constructor is only called once for all of
+// them. Changing the lifetime of the struct's service changes theirs too.
+type Out struct{}
+
+var outType = reflect.TypeOf(Out{})
+
+// isOut determines whether the type t is a struct which embeds Out.
+func isOut(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == outType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// outServices returns a service for each exported field of the Out struct t,
+// which is built by reading the field from the service of type t.
+func outServices(t reflect.Type) []*Service {
+	var svcs []*Service
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || (f.Anonymous && f.Type == outType) {
+			continue
+		}
+
+		tag := parseFieldTag(f)
+		if tag.ignore {
+			continue
+		}
+
+		i := i
+		ft := reflect.FuncOf([]reflect.Type{t}, []reflect.Type{f.Type}, false)
+		ctor := reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{args[0].Field(i)}
+		})
+
+		s := NewService(ctor.Interface())
+		if tag.name != "" {
+			s.name = tag.name
+		}
+		svcs = append(svcs, s)
+	}
+
+	return svcs
+}
@@ -0,0 +1,84 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOutResult struct {
+	Out
+
+	Dep     *testDependency
+	Repo    testRepository   `di:"name=repo"`
+	Ignored *testDependency2 `di:"-"`
+	private *testDependency2
+}
+
+func TestOut(t *testing.T) {
+	t.Run("Given Singleton", func(t *testing.T) {
+		calls := 0
+		ctn := NewContainer()
+		s := ctn.AddService(func() testOutResult {
+			calls++
+			return testOutResult{
+				Dep:  &testDependency{},
+				Repo: &testSQLRepository{},
+			}
+		})
+		assert.Equal(t, LifetimeSingleton, s.lifetime)
+
+		dep := GetService[*testDependency](ctn)
+		repo := GetService[testRepository](ctn)
+		assert.NotNil(t, dep)
+		assert.Equal(t, "sql", repo.Get())
+		assert.Same(t, repo, ctn.GetService("repo"))
+		assert.Same(t, dep, ctn.GetService("di.testOutResult").(testOutResult).Dep)
+		assert.Equal(t, 1, calls)
+
+		_, err := ctn.TryGetService("di.testDependency2")
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.NoError(t, ctn.Validate())
+	})
+
+	t.Run("Given Name In Use In Strict Mode", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetStrict(true)
+		ctn.AddNamedValue("repo", "postgres://localhost")
+
+		assert.PanicsWithError(t, "container: can not name di.testRepository repo, as the name is used by string", func() {
+			ctn.AddService(func() testOutResult { return testOutResult{} })
+		})
+		assert.Len(t, ctn.services, 1)
+	})
+
+	t.Run("Given Transient", func(t *testing.T) {
+		calls := 0
+		ctn := NewContainer()
+		ctn.AddTransient(func() testOutResult {
+			calls++
+			return testOutResult{Dep: &testDependency{}}
+		})
+
+		_ = GetService[*testDependency](ctn)
+		_ = GetService[*testDependency](ctn)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Given Scoped", func(t *testing.T) {
+		calls := 0
+		ctn := NewContainer()
+		ctn.AddScoped(func() testOutResult {
+			calls++
+			return testOutResult{Dep: &testDependency{}}
+		})
+
+		scope := ctn.CreateScope()
+		_ = GetService[*testDependency](scope)
+		_ = GetService[*testDependency](scope)
+		assert.Equal(t, 1, calls)
+
+		_ = GetService[*testDependency](ctn.CreateScope())
+		assert.Equal(t, 2, calls)
+	})
+}
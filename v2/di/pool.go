@@ -0,0 +1,51 @@
+package di
+
+import (
+	"context"
+	"sync"
+)
+
+// ScopePool is a pool of Scopes, used to avoid allocating a new Scope for
+// each unit of work, such as each request to a busy HTTP server. A ScopePool
+// is created using Container.ScopePool, and is safe for concurrent use.
+type ScopePool struct {
+	ctn  *Container
+	pool sync.Pool
+}
+
+// ScopePool returns a new ScopePool, of Scopes created by the container.
+func (ctn *Container) ScopePool() *ScopePool {
+	return &ScopePool{ctn: ctn}
+}
+
+// Get returns a Scope from the pool, with the context.Context ctx, or a new
+// Scope, if the pool is empty. The Scope should be returned to the pool,
+// using Put, once it is no longer needed.
+func (p *ScopePool) Get(ctx context.Context) *Scope {
+	s, ok := p.pool.Get().(*Scope)
+	if !ok {
+		return newScope(p.ctn, ctx)
+	}
+
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	return s
+}
+
+// Put is used to return the Scope s to the pool. The scoped services built
+// in s are disposed, in the same way as Scope.Dispose, and any errors are
+// returned. Neither s nor its scoped services should be used after Put is
+// called, as s may be returned by Get to another caller.
+//
+// If s was not created by the pool's container, or was created using
+// WithAutoDispose, it's disposed, but not pooled.
+func (p *ScopePool) Put(s *Scope) error {
+	err := s.Reset(context.Background())
+	if s.ctn == p.ctn && s.done == nil {
+		p.pool.Put(s)
+	}
+
+	return err
+}
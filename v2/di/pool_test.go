@@ -0,0 +1,87 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopePool(t *testing.T) {
+	t.Run("Given Scope Returned To Pool", func(t *testing.T) {
+		type ctxKey struct{}
+
+		builds, disposed := 0, 0
+
+		ctn := NewContainer()
+		ctn.AddScoped(func(ctx context.Context) *testService {
+			builds++
+			return &testService{x: ctx.Value(ctxKey{}).(int)}
+		}).SetDispose(func(ctx context.Context, i interface{}) {
+			disposed++
+		})
+		pool := ctn.ScopePool()
+
+		s := pool.Get(context.WithValue(context.Background(), ctxKey{}, 1))
+		assert.Equal(t, 1, GetService[*testService](s).x)
+		assert.NoError(t, pool.Put(s))
+		assert.Equal(t, 1, disposed)
+
+		// Scoped services should be built again, whether or not
+		// the pooled Scope is reused.
+		s = pool.Get(context.WithValue(context.Background(), ctxKey{}, 2))
+		assert.Equal(t, 2, GetService[*testService](s).x)
+		assert.Equal(t, 2, builds)
+	})
+
+	t.Run("Given Scope From Another Container", func(t *testing.T) {
+		disposed := false
+
+		other := NewContainer()
+		other.AddScoped(func() *testService {
+			return &testService{}
+		}).SetDispose(func(ctx context.Context, i interface{}) {
+			disposed = true
+		})
+		s := other.CreateScope()
+		_ = s.GetService("di.testService")
+
+		pool := NewContainer().ScopePool()
+		assert.NoError(t, pool.Put(s))
+		assert.True(t, disposed)
+		assert.NotSame(t, s, pool.Get(context.Background()))
+	})
+}
+
+func BenchmarkScopePool_Get(b *testing.B) {
+	ctn := NewContainer()
+	ctn.AddScoped(func() *testService {
+		return &testService{}
+	})
+	pool := ctn.ScopePool()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := pool.Get(ctx)
+		_ = s.GetService("di.testService")
+		_ = pool.Put(s)
+	}
+}
+
+func BenchmarkContainer_CreateScope(b *testing.B) {
+	ctn := NewContainer()
+	ctn.AddScoped(func() *testService {
+		return &testService{}
+	})
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := ctn.CreateScopeWithContext(ctx)
+		_ = s.GetService("di.testService")
+		_ = s.Dispose(ctx)
+	}
+}
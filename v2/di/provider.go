@@ -0,0 +1,82 @@
+package di
+
+import "reflect"
+
+// Provider is used as a constructor parameter to depend on a service of
+// type T, which is resolved on demand, after the constructor has returned.
+// This can be used to break a circular dependency, where a service holds a
+// Provider for a service which depends on it:
+//
+//	func NewA(b di.Provider[*B]) *A
+//	func NewB(a *A) *B
+//
+// Unlike Lazy, a Provider does not cache the service, so each call to Get
+// resolves it from the container, in the same way as a factory function.
+type Provider[T any] struct {
+	resolve func() (interface{}, error)
+}
+
+// Get resolves and returns the service. Calling Get from the constructor
+// the Provider was injected into will fail, if it's part of a cycle.
+func (p Provider[T]) Get() (T, error) {
+	var v T
+	if p.resolve == nil {
+		return v, &notFoundError{typ: p.provided()}
+	}
+
+	impl, err := p.resolve()
+	if err != nil || impl == nil {
+		return v, err
+	}
+
+	return impl.(T), nil
+}
+
+// MustGet resolves and returns the service, in the
+// same way as Get, however, panics if it fails.
+func (p Provider[T]) MustGet() T {
+	v, err := p.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// providerDependency is implemented by *Provider, and is used to
+// detect and populate provider dependencies during a build.
+type providerDependency interface {
+	provided() reflect.Type
+	setResolve(f func() (interface{}, error))
+}
+
+var providerDependencyType = reflect.TypeOf((*providerDependency)(nil)).Elem()
+
+func (Provider[T]) provided() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (p *Provider[T]) setResolve(f func() (interface{}, error)) {
+	p.resolve = f
+}
+
+// isProvider determines whether the type t is a Provider dependency.
+func isProvider(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(providerDependencyType)
+}
+
+// makeProvider is used to create a Provider of type t, which resolves its
+// service using r each time it's called. As with factories, the Provider
+// captures r, so it resolves scoped services from the Scope it was built in,
+// which is safe to do while the Scope is in use.
+func makeProvider(t reflect.Type, r resolver) reflect.Value {
+	pv := reflect.New(t)
+	pd := pv.Interface().(providerDependency)
+
+	st := pd.provided()
+	pd.setResolve(func() (interface{}, error) {
+		return r.Resolve(st)
+	})
+
+	return pv.Elem()
+}
@@ -0,0 +1,106 @@
+package di
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProviderA struct {
+	b Provider[*testProviderB]
+}
+
+type testProviderB struct {
+	a *testProviderA
+}
+
+func TestProvider(t *testing.T) {
+	t.Run("Given Circular Dependency", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func(b Provider[*testProviderB]) *testProviderA {
+			return &testProviderA{b: b}
+		})
+		ctn.AddSingleton(func(a *testProviderA) *testProviderB {
+			return &testProviderB{a: a}
+		})
+
+		a := GetService[*testProviderA](ctn)
+		b, err := a.b.Get()
+		assert.NoError(t, err)
+		assert.Same(t, a, b.a)
+		assert.Same(t, b, GetService[*testProviderB](ctn))
+		assert.Same(t, b, a.b.MustGet())
+		assert.NoError(t, ctn.Validate())
+	})
+
+	t.Run("Where Get Is Called By Constructor", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func(b Provider[*testProviderB]) (*testProviderA, error) {
+			if _, err := b.Get(); err != nil {
+				return nil, err
+			}
+			return &testProviderA{b: b}, nil
+		})
+		ctn.AddSingleton(func(a *testProviderA) *testProviderB {
+			return &testProviderB{a: a}
+		})
+
+		_, err := ctn.TryGetService("di.testProviderA")
+		assert.True(t, errors.Is(err, ErrCircularDependency))
+	})
+
+	t.Run("Where Service Is Not Registered", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func(b Provider[*testProviderB]) *testProviderA {
+			return &testProviderA{b: b}
+		})
+
+		a := GetService[*testProviderA](ctn)
+		_, err := a.b.Get()
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.Error(t, ctn.Validate())
+	})
+
+	t.Run("Given Zero Value", func(t *testing.T) {
+		var p Provider[*testProviderB]
+
+		_, err := p.Get()
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+		assert.Panics(t, func() {
+			_ = p.MustGet()
+		})
+	})
+}
+
+func TestProvider_Scoped(t *testing.T) {
+	type consumer struct {
+		svc Provider[*testService]
+	}
+
+	ctn := NewContainer()
+	ctn.AddScoped(func() *testService { return &testService{} })
+	ctn.AddScoped(func() *testDependency2 { return &testDependency2{} })
+	ctn.AddScoped(func(p Provider[*testService]) *consumer {
+		return &consumer{svc: p}
+	})
+	scope := ctn.CreateScope()
+	c := GetService[*consumer](scope)
+
+	var wg sync.WaitGroup
+	svcs := make([]*testService, 20)
+	for i := range svcs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			svcs[i] = c.svc.MustGet()
+			_ = scope.GetService("di.testDependency2")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, v := range svcs {
+		assert.Same(t, scope.GetService("di.testService"), v)
+	}
+}
@@ -0,0 +1,188 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Resolver is used to resolve services by their type, within the
+// context.Context of a resolution. Container and Scope implement Resolver,
+// as do the resolvers used to resolve the dependencies of a service being
+// built, which also track the services being built, to detect cycles.
+type Resolver interface {
+	// Resolve is used to resolve a service by its type.
+	Resolve(t reflect.Type) (interface{}, error)
+
+	// Context returns the context.Context of the resolution, which is
+	// provided to constructors with a context.Context parameter.
+	Context() context.Context
+}
+
+// resolver is used to resolve the dependencies of a service being built.
+type resolver interface {
+	Resolver
+
+	// resolveNamed is used to resolve a dependency by its service's name.
+	resolveNamed(name string) (interface{}, error)
+}
+
+// containerResolver resolves dependencies from a Container, with the
+// context.Context ctx, as dependencies of the last service in path.
+type containerResolver struct {
+	ctn  *Container
+	ctx  context.Context
+	path resolutionPath
+}
+
+func (r containerResolver) Resolve(t reflect.Type) (interface{}, error) {
+	return r.ctn.resolve(r.ctx, r.path, t)
+}
+
+func (r containerResolver) Context() context.Context {
+	return r.ctx
+}
+
+func (r containerResolver) resolveNamed(name string) (interface{}, error) {
+	return r.ctn.resolveNamed(r.ctx, r.path, name)
+}
+
+// scopeResolver resolves dependencies from a Scope, with the context.Context
+// ctx, as dependencies of the last service in path.
+type scopeResolver struct {
+	scope *Scope
+	ctx   context.Context
+	path  resolutionPath
+}
+
+func (r scopeResolver) Resolve(t reflect.Type) (interface{}, error) {
+	return r.scope.resolve(r.ctx, r.path, t)
+}
+
+func (r scopeResolver) Context() context.Context {
+	return r.ctx
+}
+
+func (r scopeResolver) resolveNamed(name string) (interface{}, error) {
+	return r.scope.resolveNamed(r.ctx, r.path, name)
+}
+
+// resolutionPath is the chain of services being built during a
+// resolution, where each service is a dependency of the previous.
+type resolutionPath []*resolutionStep
+
+// resolutionStep is a service in a resolutionPath. Once the service has
+// been built, the step is marked as done, so that dependencies resolved
+// lazily afterwards, such as by a factory, are not mistaken for a cycle.
+type resolutionStep struct {
+	svc  *Service
+	done atomic.Bool
+
+	// The transient services built during the resolution,
+	// which is only set on the first step in the path.
+	transients *transientCache
+}
+
+// transientCache holds the transient services built during a single
+// resolution, so each is only built once, however many services in
+// the resolution depend on it.
+type transientCache struct {
+	mu       sync.Mutex
+	services map[*Service]interface{}
+}
+
+// transient returns the instance of the transient service s built earlier
+// in the resolution, if any. Once the resolution is complete, dependencies
+// resolved lazily, such as by a factory, are always built again.
+func (p resolutionPath) transient(s *Service) (interface{}, bool) {
+	if len(p) == 0 || p[0].done.Load() {
+		return nil, false
+	}
+
+	c := p[0].transients
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	impl, ok := c.services[s]
+	return impl, ok
+}
+
+// shareTransient is used to store impl, if s is a transient service, so that
+// it is used for other dependencies on s for the rest of the resolution.
+func (p resolutionPath) shareTransient(s *Service, impl interface{}) {
+	if len(p) == 0 || !s.isTransient() {
+		return
+	}
+
+	c := p[0].transients
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.services[s] = impl
+}
+
+// with returns a copy of the path with s appended. If s is already
+// being built in the path, an error is returned describing the cycle.
+func (p resolutionPath) with(s *Service) (resolutionPath, error) {
+	for i, step := range p {
+		if step.svc != s || step.done.Load() {
+			continue
+		}
+
+		names := make([]string, 0, len(p)-i+1)
+		for _, cs := range p[i:] {
+			if !cs.done.Load() {
+				names = append(names, cs.svc.Name())
+			}
+		}
+		names = append(names, s.Name())
+
+		return nil, fmt.Errorf("%w: %s", ErrCircularDependency, strings.Join(names, " -> "))
+	}
+
+	if len(p) == 0 {
+		return resolutionPath{&resolutionStep{
+			svc:        s,
+			transients: &transientCache{services: make(map[*Service]interface{})},
+		}}, nil
+	}
+
+	np := make(resolutionPath, len(p), len(p)+1)
+	copy(np, p)
+	return append(np, &resolutionStep{svc: s}), nil
+}
+
+// exclude returns the services in svcs which are not being built in the
+// path, so a service can depend on a collection of services it belongs
+// to, such as a composite, without being mistaken for a cycle.
+func (p resolutionPath) exclude(svcs []*Service) []*Service {
+	building := make(map[*Service]bool, len(p))
+	for _, step := range p {
+		if !step.done.Load() {
+			building[step.svc] = true
+		}
+	}
+
+	if len(building) == 0 {
+		return svcs
+	}
+
+	out := make([]*Service, 0, len(svcs))
+	for _, s := range svcs {
+		if !building[s] {
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
+
+// complete marks the last service in the path as built.
+func (p resolutionPath) complete() {
+	if len(p) > 0 {
+		p[len(p)-1].done.Store(true)
+	}
+}
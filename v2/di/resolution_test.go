@@ -0,0 +1,211 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCycleA struct {
+	b *testCycleB
+}
+
+type testCycleB struct {
+	a *testCycleA
+}
+
+type testCycleFactory struct {
+	next func() (*testCycleFactory, error)
+}
+
+func TestResolution_CircularDependency(t *testing.T) {
+	t.Run("Where Singletons Depend On Each Other", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(b *testCycleB) *testCycleA {
+			return &testCycleA{b: b}
+		}).AsSingleton()
+		ctn.AddService(func(a *testCycleA) *testCycleB {
+			return &testCycleB{a: a}
+		}).AsSingleton()
+
+		defer func() {
+			err := recover().(error)
+			assert.True(t, errors.Is(err, ErrCircularDependency))
+			assert.Contains(t, err.Error(), "di.testCycleA -> di.testCycleB -> di.testCycleA")
+		}()
+
+		// Should panic, rather than hang.
+		_ = ctn.GetService("di.testCycleA")
+	})
+
+	t.Run("Where Transients Depend On Each Other In A Scope", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(b *testCycleB) *testCycleA {
+			return &testCycleA{b: b}
+		}).AsScoped()
+		ctn.AddService(func(a *testCycleA) *testCycleB {
+			return &testCycleB{a: a}
+		})
+
+		s := ctn.CreateScope()
+
+		defer func() {
+			err := recover().(error)
+			assert.Contains(t, err.Error(), "di.testCycleA -> di.testCycleB -> di.testCycleA")
+		}()
+
+		_ = s.GetService("di.testCycleA")
+	})
+
+	t.Run("Where Factory Of Own Type Is Called After Build", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(f func() (*testCycleFactory, error)) *testCycleFactory {
+			return &testCycleFactory{next: f}
+		})
+
+		f := GetService[*testCycleFactory](ctn)
+
+		// This is not a cycle, as f has already been built.
+		next, err := f.next()
+		assert.Nil(t, err)
+		assert.NotNil(t, next)
+	})
+}
+
+type testClock struct {
+	n int
+}
+
+type testClockUserB struct {
+	clock *testClock
+}
+
+type testClockUserC struct {
+	clock *testClock
+}
+
+type testClockRoot struct {
+	b *testClockUserB
+	c *testClockUserC
+}
+
+type testClockFactory struct {
+	clock func() *testClock
+}
+
+func TestResolution_SharedTransients(t *testing.T) {
+	newContainer := func(calls *int, root ServiceLifetime) *Container {
+		ctn := NewContainer()
+		ctn.AddTransient(func() *testClock {
+			*calls++
+			return &testClock{n: *calls}
+		})
+		ctn.AddTransient(func(c *testClock) *testClockUserB {
+			return &testClockUserB{clock: c}
+		})
+		ctn.AddTransient(func(c *testClock) *testClockUserC {
+			return &testClockUserC{clock: c}
+		})
+		ctn.AddService(func(b *testClockUserB, c *testClockUserC) *testClockRoot {
+			return &testClockRoot{b: b, c: c}
+		}).setLifetime(root)
+		return ctn
+	}
+
+	t.Run("Given Diamond Dependency", func(t *testing.T) {
+		calls := 0
+		ctn := newContainer(&calls, LifetimeTransient)
+
+		root := GetService[*testClockRoot](ctn)
+		assert.Same(t, root.b.clock, root.c.clock)
+		assert.Equal(t, 1, calls)
+
+		// Each top-level resolution should build a new instance.
+		other := GetService[*testClockRoot](ctn)
+		assert.NotSame(t, root.b.clock, other.b.clock)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Given Diamond Dependency In Scope", func(t *testing.T) {
+		calls := 0
+		ctn := newContainer(&calls, LifetimeScoped)
+
+		root := GetService[*testClockRoot](ctn.CreateScope())
+		assert.Same(t, root.b.clock, root.c.clock)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Where Factory Is Called After Build", func(t *testing.T) {
+		calls := 0
+		ctn := newContainer(&calls, LifetimeTransient)
+		ctn.AddTransient(func(b *testClockUserB, f func() *testClock) *testClockFactory {
+			return &testClockFactory{clock: f}
+		})
+
+		f := GetService[*testClockFactory](ctn)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 2, f.clock().n)
+	})
+}
+
+// addChain adds n services to ctn, each of a distinct generated type, where
+// each depends on the next. The name of the first service is returned.
+func addChain(ctn *Container, n int) string {
+	types := make([]reflect.Type, n)
+	for i := range types {
+		types[i] = reflect.StructOf([]reflect.StructField{
+			{Name: fmt.Sprintf("Link%d", i), Type: reflect.TypeOf(0)},
+		})
+	}
+
+	for i, t := range types {
+		t := t
+
+		var in []reflect.Type
+		if i < n-1 {
+			in = []reflect.Type{types[i+1]}
+		}
+
+		ft := reflect.FuncOf(in, []reflect.Type{t}, false)
+		ctor := reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.New(t).Elem()}
+		})
+		ctn.AddService(ctor.Interface())
+	}
+
+	return serviceName(types[0])
+}
+
+func TestResolution_MaxDepth(t *testing.T) {
+	t.Run("Where Chain Is Within Max Depth", func(t *testing.T) {
+		ctn := NewContainer(WithMaxDepth(10))
+		name := addChain(ctn, 11)
+
+		_, err := ctn.TryGetService(name)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Where Chain Exceeds Max Depth", func(t *testing.T) {
+		ctn := NewContainer(WithMaxDepth(10))
+		name := addChain(ctn, 12)
+
+		_, err := ctn.TryGetService(name)
+		assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+		assert.Contains(t, err.Error(), "is more than 10 levels deep")
+	})
+
+	t.Run("Where Chain Exceeds Max Depth In Scope", func(t *testing.T) {
+		ctn := NewContainer(WithMaxDepth(100))
+		name := addChain(ctn, 1000)
+
+		defer func() {
+			err := recover().(error)
+			assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+		}()
+
+		_ = ctn.CreateScope().GetService(name)
+	})
+}
@@ -2,59 +2,496 @@ package di
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
 )
 
+// Scope is used to resolve scoped services, where each Scope has its own
+// instances. A constructor resolved in a Scope can depend on the *Scope
+// itself, such as to resolve other scoped services lazily, however, it
+// should not be used until the constructor has returned, as the Scope is
+// locked while its services are built. Resolving such a constructor from
+// a Container returns ErrScopedOutsideScope.
+//
+// Factories and Providers injected into services built in a Scope resolve
+// from it without locking it, so they can be called while the Scope is in
+// use, including by the constructor of another service in the Scope.
 type Scope struct {
 	mu  *sync.Mutex
 	ctn *Container
 	ctx context.Context
 
-	// A map of scoped services, where the key is the type
-	// of the service and the value is the built service.
-	services map[reflect.Type]interface{}
+	// A map of scoped services, where the key is the service
+	// and its discriminator, and the value is the built service.
+	// Both services and built are guarded by servicesMu.
+	services   map[scopeKey]interface{}
+	servicesMu sync.Mutex
+
+	// The built services, in the order they were built.
+	built []scopedInstance
+
+	// Used to stop the goroutine started by WithAutoDispose.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// scopeContextKey is the key used to store a Scope in a context.Context.
+type scopeContextKey struct{}
+
+// ContextWithScope returns a copy of ctx, which carries the Scope s.
+func ContextWithScope(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, s)
+}
+
+// ScopeFromContext returns the Scope carried by ctx, if any.
+func ScopeFromContext(ctx context.Context) (*Scope, bool) {
+	s, ok := ctx.Value(scopeContextKey{}).(*Scope)
+	return s, ok
+}
+
+// ScopeOption is used to configure a Scope when it is created.
+type ScopeOption func(s *Scope)
+
+// WithAutoDispose configures a Scope to dispose itself when its
+// context.Context is cancelled. A goroutine is started to wait for
+// the context, which runs until the context is done, or the Scope is
+// disposed or closed. Scopes with a context which may never be
+// cancelled should be closed, using Close, to stop the goroutine.
+//
+// Errors returned when disposing the Scope automatically are discarded.
+func WithAutoDispose() ScopeOption {
+	return func(s *Scope) {
+		s.done = make(chan struct{})
+	}
 }
 
-func newScope(ctn *Container, ctx context.Context) *Scope {
-	return &Scope{
+// scopedInstance is an instance of a scoped service, built in a Scope.
+type scopedInstance struct {
+	svc  *Service
+	impl interface{}
+}
+
+// scopeKey is used to key the built services in a Scope.
+type scopeKey struct {
+	svc  *Service
+	disc string
+}
+
+func newScope(ctn *Container, ctx context.Context, opts ...ScopeOption) *Scope {
+	s := &Scope{
 		mu:       &sync.Mutex{},
 		ctn:      ctn,
 		ctx:      ctx,
-		services: make(map[reflect.Type]interface{}),
+		services: make(map[scopeKey]interface{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.done != nil {
+		go s.disposeOnDone()
+	}
+
+	return s
+}
+
+// disposeOnDone waits for the Scope's context.Context to be done, then
+// disposes the Scope. It returns early if the Scope is closed.
+func (s *Scope) disposeOnDone() {
+	select {
+	case <-s.ctx.Done():
+		// Both channels may be ready, in which case the Scope
+		// was closed before its context was observed as done.
+		select {
+		case <-s.done:
+		default:
+			_ = s.Dispose(context.Background())
+		}
+	case <-s.done:
 	}
 }
 
+// Close is used to stop the Scope from disposing itself when its
+// context.Context is cancelled, as configured by WithAutoDispose.
+// Scoped services are not disposed. It is safe to call Close more
+// than once, or on a Scope created without WithAutoDispose.
+func (s *Scope) Close() {
+	if s.done == nil {
+		return
+	}
+
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// CreateScope is used to create a nested Scope, which inherits this Scope's
+// context.Context, but has its own scoped services. Scoped services already
+// built in this Scope are not visible to the nested Scope, and are built
+// again when resolved from it.
+func (s *Scope) CreateScope() *Scope {
+	return newScope(s.ctn, s.ctx)
+}
+
 func (s *Scope) GetService(name string) interface{} {
+	return s.GetServiceWithContext(s.ctx, name)
+}
+
+// GetServiceWithContext is used to resolve a service by name, using ctx in
+// place of the Scope's context.Context. This is used to resolve services
+// partitioned by a context value, using Service.AsScopedBy.
+//
+// Singletons are always built with the Container's context.Context, rather
+// than ctx or the Scope's, as they outlive the Scope.
+func (s *Scope) GetServiceWithContext(ctx context.Context, name string) interface{} {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	svc := s.ctn.getServiceInfo(name)
-	if svc.lifetime != LifetimeScoped {
-		return s.ctn.GetService(name)
+	if svc.lifetime != LifetimeScoped && !svc.isTransient() {
+		return s.ctn.GetServiceWithContext(s.ctn.ctx, name)
+	}
+	impl, err := s.get(ctx, nil, svc)
+	if err != nil {
+		panic(err)
+	}
+	return impl
+}
+
+// getServiceByType is used to resolve the service of type t, in the same way
+// as GetService, where services which aren't built in the Scope are resolved
+// by the Container, so they're passed through its middleware.
+func (s *Scope) getServiceByType(t reflect.Type) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, _ := s.ctn.getServiceInfoByType(t)
+	if svc != nil && svc.lifetime != LifetimeScoped && !svc.isTransient() {
+		return s.ctn.getServiceByTypeWithContext(s.ctn.ctx, t)
+	}
+
+	impl, err := s.resolve(s.ctx, nil, t)
+	if err != nil {
+		panic(err)
+	}
+	return impl
+}
+
+// GetGroup is used to retrieve the services in a given group, in the same
+// order as Container.GetGroup. Scoped services are built and stored in the
+// Scope, in the same way as GetService.
+func (s *Scope) GetGroup(group string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.buildAll(s.ctn.getGroupInfo(group))
+}
+
+// GetServices is used to retrieve the services of type t, in the same order
+// as Container.GetServices. Scoped services are built and stored in the
+// Scope, whereas other services are resolved from the Container.
+func (s *Scope) GetServices(t reflect.Type) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.buildAll(s.ctn.servicesOf(t))
+}
+
+// buildAll is used to build each of the services in svcs, in order, in
+// the same way as Container.buildAll. The caller must hold s.mu.
+func (s *Scope) buildAll(svcs []*Service) []interface{} {
+	vs := make([]interface{}, 0, len(svcs))
+	for _, svc := range svcs {
+		v, err := s.get(s.ctx, nil, svc)
+		if err != nil {
+			panic(err)
+		}
+		vs = append(vs, v)
 	}
-	impl, ok := s.services[svc.typ]
+	return vs
+}
+
+// get is used to build the service svc, as a dependency of the last service
+// in path. If svc is scoped, the Scope's instance is returned, and transient
+// services are built in the Scope, so they can depend on scoped services.
+// Otherwise, it's built by the Container, with its context.Context, as it
+// outlives the Scope.
+func (s *Scope) get(ctx context.Context, path resolutionPath, svc *Service) (interface{}, error) {
+	switch {
+	case svc.lifetime == LifetimeScoped:
+		return s.getScoped(ctx, path, svc)
+	case svc.isTransient():
+		return s.getTransient(ctx, path, svc)
+	}
+
+	return s.ctn.build(s.ctn.ctx, path, svc)
+}
+
+// getTransient is used to build the transient service svc in the Scope, as
+// a dependency of the last service in path, where it is shared with other
+// dependencies on svc in the same resolution.
+func (s *Scope) getTransient(ctx context.Context, path resolutionPath, svc *Service) (interface{}, error) {
+	impl, ok := path.transient(svc)
+	svc.resolved(ok)
 	if ok {
-		return impl
+		return impl, nil
+	}
+
+	impl, err := s.build(ctx, path, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	path.shareTransient(svc, impl)
+
+	return impl, nil
+}
+
+// GetKeyedService is used to retrieve the service of type t, which has been
+// registered with the given key, in the same way as Container.GetKeyedService,
+// where scoped services are built and stored in the Scope.
+func (s *Scope) GetKeyedService(t reflect.Type, key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := s.ctn.keyedOf(t, key)
+	if svc == nil {
+		panic(&notFoundError{typ: t, key: key})
 	}
-	impl, err := svc.build(s.getService)
+
+	impl, err := s.get(s.ctx, nil, svc)
 	if err != nil {
-		panic(fmt.Errorf("container: failed to build %s, %v", svc.Name(), err))
+		panic(err)
 	}
-	s.services[svc.typ] = impl
 	return impl
 }
 
-// getService wraps the Scope's Container's implementation of
-// getService(reflect.Type) to provide scoped services and the
-// Scope's context.Context.
-func (s *Scope) getService(typ reflect.Type) (interface{}, error) {
-	if typ.String() == "context.Context" {
-		return s.ctx, nil
+// getKeyedDependency is used to resolve a Keyed dependency, of type t, in
+// the same way as Container.getKeyedService, where scoped services are
+// built in the Scope.
+func (s *Scope) getKeyedDependency(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	kv := reflect.New(t)
+	kd := kv.Interface().(keyedDependency)
+	typ, key := kd.keyed()
+
+	svc := s.ctn.keyedOf(typ, key)
+	if svc == nil {
+		return nil, &notFoundError{typ: typ, key: key}
+	}
+
+	impl, err := s.get(ctx, path, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	kd.set(impl)
+	return kv.Elem().Interface(), nil
+}
+
+// getServiceMap is used to resolve a map dependency, of type t, in the
+// same way as Container.getServiceMap, where scoped services are built
+// in the Scope.
+func (s *Scope) getServiceMap(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	svcs := path.exclude(s.ctn.assignableOf(t.Elem()))
+
+	return buildServiceMap(t, svcs, func(svc *Service) (interface{}, error) {
+		return s.get(ctx, path, svc)
+	})
+}
+
+// getServiceSlice is used to resolve a slice dependency, of type t, in the
+// same way as Container.getServiceSlice, where scoped services are built
+// in the Scope.
+func (s *Scope) getServiceSlice(ctx context.Context, path resolutionPath, t reflect.Type) (interface{}, error) {
+	et := t.Elem()
+	elems := path.exclude(s.ctn.servicesOf(et))
+
+	svcs := reflect.MakeSlice(t, 0, len(elems))
+	for _, svc := range elems {
+		v, err := s.get(ctx, path, svc)
+		if err != nil {
+			return nil, err
+		}
+
+		svcs = reflect.Append(svcs, valueOf(v, et))
 	}
-	impl, ok := s.services[typ]
+
+	return svcs.Interface(), nil
+}
+
+// getScoped returns the Scope's instance of the scoped service, svc,
+// building it if it hasn't already been.
+func (s *Scope) getScoped(ctx context.Context, path resolutionPath, svc *Service) (interface{}, error) {
+	key := scopeKey{svc: svc, disc: svc.discriminator(ctx)}
+	s.servicesMu.Lock()
+	impl, ok := s.services[key]
+	s.servicesMu.Unlock()
+	svc.resolved(ok)
 	if ok {
 		return impl, nil
 	}
-	return s.ctn.getService(typ)
+	impl, err := s.build(ctx, path, svc)
+	if err != nil {
+		return nil, err
+	}
+	return s.storeScoped(ctx, key, svc, impl), nil
+}
+
+// storeScoped is used to store impl as the Scope's instance of svc, and
+// returns it. Where another instance was stored while impl was built, such
+// as by a factory called from another goroutine, that instance is returned
+// instead, and impl is disposed, discarding any error.
+func (s *Scope) storeScoped(ctx context.Context, key scopeKey, svc *Service, impl interface{}) interface{} {
+	s.servicesMu.Lock()
+	if existing, ok := s.services[key]; ok {
+		s.servicesMu.Unlock()
+		_ = svc.disposeInstance(ctx, impl)
+		return existing
+	}
+	s.services[key] = impl
+	s.built = append(s.built, scopedInstance{svc: svc, impl: impl})
+	s.servicesMu.Unlock()
+
+	return impl
+}
+
+// Dispose is used to clean up the scoped services built in the Scope,
+// using each service's DisposeFunc. Services are disposed in the reverse
+// order in which they were built, so dependents are disposed before their
+// dependencies.
+//
+// Once disposed, the Scope can still be used, however, scoped services
+// will be built again. Any errors returned by a DisposeFuncE are joined
+// and returned.
+//
+// If the Scope was created using WithAutoDispose, it will no longer
+// be disposed when its context.Context is cancelled.
+func (s *Scope) Dispose(ctx context.Context) error {
+	s.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.servicesMu.Lock()
+	built := s.built
+	s.built = nil
+	s.services = make(map[scopeKey]interface{})
+	s.servicesMu.Unlock()
+
+	return dispose(ctx, built)
+}
+
+// Reset is used to reuse the Scope, such as for each job in a worker loop,
+// rather than creating a new Scope. The scoped services built in the Scope
+// are disposed, using ctx, in the same way as Dispose, then ctx replaces the
+// Scope's context.Context, and scoped services are built again when resolved.
+//
+// The Scope's storage is kept, to avoid allocating it again. Reset must not
+// be called while the Scope is in use by other goroutines, as services they
+// have resolved would be disposed. If the Scope was created using
+// WithAutoDispose, it will no longer be disposed when a context is cancelled.
+func (s *Scope) Reset(ctx context.Context) error {
+	s.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.servicesMu.Lock()
+	defer s.servicesMu.Unlock()
+
+	err := dispose(ctx, s.built)
+	clear(s.built)
+	s.built = s.built[:0]
+	clear(s.services)
+	s.ctx = ctx
+
+	return err
+}
+
+// dispose is used to dispose the scoped services in built, in the
+// reverse order in which they were built.
+func dispose(ctx context.Context, built []scopedInstance) error {
+	var errs []error
+	for i := len(built) - 1; i >= 0; i-- {
+		if err := built[i].svc.disposeInstance(ctx, built[i].impl); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// build is used to build the service svc within the Scope, as a
+// dependency of the last service in path.
+func (s *Scope) build(ctx context.Context, path resolutionPath, svc *Service) (interface{}, error) {
+	path, err := s.ctn.extend(path, svc)
+	if err != nil {
+		return nil, err
+	}
+	defer path.complete()
+	impl, err := svc.build(scopeResolver{scope: s, ctx: ctx, path: path}, len(path)-1)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s, %w", ErrBuildFailed, svc.Name(), err)
+	}
+	return impl, nil
+}
+
+// Resolve is used to resolve a service by its type, in the same way as
+// Container.Resolve, where scoped services are built in the Scope, and the
+// Scope's context.Context is provided. This implements Resolver.
+func (s *Scope) Resolve(typ reflect.Type) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.resolve(s.ctx, nil, typ)
+}
+
+// Context returns the Scope's context.Context. This implements Resolver.
+func (s *Scope) Context() context.Context {
+	return s.ctx
+}
+
+// resolve is used to resolve a dependency of type typ, where ctx
+// is the context.Context of the resolution and path is the chain
+// of services currently being built. Services resolved from the
+// Container are given its context.Context, rather than ctx.
+func (s *Scope) resolve(ctx context.Context, path resolutionPath, typ reflect.Type) (interface{}, error) {
+	if typ == contextType {
+		return ctx, nil
+	}
+	if typ == serviceProviderType || typ == scopeType {
+		if svc, _ := s.ctn.getServiceInfoByType(typ); svc == nil {
+			return s, nil
+		}
+	}
+	if isKeyed(typ) {
+		return s.getKeyedDependency(ctx, path, typ)
+	}
+	svc, err := s.ctn.getServiceInfoByType(typ)
+	if err != nil {
+		return nil, err
+	}
+	if svc != nil && (svc.lifetime == LifetimeScoped || svc.isTransient()) {
+		return s.get(ctx, path, svc)
+	}
+	if svc == nil && typ.Kind() == reflect.Slice {
+		return s.getServiceSlice(ctx, path, typ)
+	}
+	if svc == nil && isServiceMap(typ) {
+		return s.getServiceMap(ctx, path, typ)
+	}
+	return s.ctn.resolve(s.ctn.ctx, path, typ)
+}
+
+// resolveNamed is used to resolve a dependency by its service's name,
+// where ctx is the context.Context of the resolution and path is the
+// chain of services currently being built.
+func (s *Scope) resolveNamed(ctx context.Context, path resolutionPath, name string) (interface{}, error) {
+	svc := s.ctn.lookupName(name)
+	if svc != nil && (svc.lifetime == LifetimeScoped || svc.isTransient()) {
+		return s.get(ctx, path, svc)
+	}
+	return s.ctn.resolveNamed(s.ctn.ctx, path, name)
 }
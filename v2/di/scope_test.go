@@ -2,8 +2,11 @@ package di
 
 import (
 	"context"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -116,7 +119,8 @@ func TestScope_GetService(t *testing.T) {
 	})
 
 	t.Run("Where context.Context Is A Dependency", func(t *testing.T) {
-		ctx := context.Background()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 		ctor1 := func(c context.Context) *testDependency {
 			assert.Same(t, ctx, c)
 			return &testDependency{}
@@ -196,3 +200,420 @@ func TestScope_GetService(t *testing.T) {
 		})
 	})
 }
+
+func TestScope_GetServiceWithContext(t *testing.T) {
+	type traceKey struct{}
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testService {
+		return &testService{}
+	}).SetName("MyService").AsScopedBy(func(ctx context.Context) string {
+		id, _ := ctx.Value(traceKey{}).(string)
+		return id
+	})
+
+	s := ctn.CreateScope()
+
+	ctx1 := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	ctx2 := context.WithValue(context.Background(), traceKey{}, "trace-2")
+
+	v1 := s.GetServiceWithContext(ctx1, "MyService")
+	v2 := s.GetServiceWithContext(ctx2, "MyService")
+	assert.NotSame(t, v1, v2)
+
+	// Instances should be re-used for the same discriminator.
+	assert.Same(t, v1, s.GetServiceWithContext(ctx1, "MyService"))
+	assert.Same(t, v2, s.GetServiceWithContext(ctx2, "MyService"))
+}
+
+func TestScope_TransientService(t *testing.T) {
+	type ctxKey struct{}
+
+	newContainer := func() *Container {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddTransient(func(ctx context.Context, d *testDependency) *testService {
+			return &testService{dep: d, x: ctx.Value(ctxKey{}).(int)}
+		})
+		return ctn
+	}
+
+	t.Run("Given Scope Context", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, 1)
+		scope := newContainer().CreateScopeWithContext(ctx)
+
+		v := scope.GetService("di.testService").(*testService)
+		assert.Equal(t, 1, v.x)
+		assert.Same(t, scope.GetService("di.testDependency"), v.dep)
+
+		v = GetService[*testService](scope)
+		assert.Equal(t, 1, v.x)
+	})
+
+	t.Run("Where Transient Is A Dependency", func(t *testing.T) {
+		ctn := newContainer()
+		ctn.AddScoped(func(s *testService) *testClock {
+			return &testClock{n: s.x}
+		})
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, 2)
+		v := GetService[*testClock](ctn.CreateScopeWithContext(ctx))
+		assert.Equal(t, 2, v.n)
+	})
+}
+
+type testScopedFactory struct {
+	scope *Scope
+}
+
+func TestScope_SingletonContext(t *testing.T) {
+	type ctxKey struct{}
+	type singleton struct{ ctx context.Context }
+
+	newContainer := func() *Container {
+		ctn := NewContainer(WithContext(context.WithValue(context.Background(), ctxKey{}, "container")))
+		ctn.AddSingleton(func(ctx context.Context) *singleton {
+			return &singleton{ctx: ctx}
+		})
+		ctn.AddScoped(func(s *singleton) *testService {
+			return &testService{}
+		})
+		return ctn
+	}
+
+	t.Run("Where Singleton Is Resolved From Scope", func(t *testing.T) {
+		ctn := newContainer()
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+		scope := ctn.CreateScopeWithContext(ctx)
+
+		v := GetService[*singleton](scope)
+		cancel()
+		assert.NoError(t, v.ctx.Err())
+		assert.Equal(t, "container", v.ctx.Value(ctxKey{}))
+	})
+
+	t.Run("Where Singleton Is A Dependency Of Scoped Service", func(t *testing.T) {
+		ctn := newContainer()
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+		scope := ctn.CreateScopeWithContext(ctx)
+
+		_ = scope.GetService("di.testService")
+		cancel()
+
+		v := GetService[*singleton](ctn)
+		assert.NoError(t, v.ctx.Err())
+		assert.Equal(t, "container", v.ctx.Value(ctxKey{}))
+	})
+}
+
+func TestScope_ScopeDependency(t *testing.T) {
+	newContainer := func() *Container {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddScoped(func(s *Scope) *testScopedFactory {
+			return &testScopedFactory{scope: s}
+		})
+		return ctn
+	}
+
+	t.Run("Given Scope", func(t *testing.T) {
+		scope := newContainer().CreateScope()
+
+		f := GetService[*testScopedFactory](scope)
+		assert.Same(t, scope, f.scope)
+		assert.Same(t, scope.GetService("di.testDependency"), f.scope.GetService("di.testDependency"))
+	})
+
+	t.Run("Given Container", func(t *testing.T) {
+		ctn := newContainer()
+		ctn.AddTransient(func(s *Scope) *testService {
+			return &testService{}
+		})
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, ErrScopedOutsideScope)
+		assert.Contains(t, err.Error(), "*di.Scope")
+	})
+}
+
+func TestScope_GetServices(t *testing.T) {
+	newContainer := func(builds *int) *Container {
+		ctn := NewContainer()
+		Register[testRepository](ctn, func() *testSQLRepository {
+			return &testSQLRepository{}
+		}).AsSingleton()
+		Register[testRepository](ctn, func() *testMemoryRepository {
+			*builds++
+			return &testMemoryRepository{}
+		}).AsScoped()
+		return ctn
+	}
+
+	t.Run("Given Scoped And Singleton Services", func(t *testing.T) {
+		builds := 0
+		ctn := newContainer(&builds)
+		scope := ctn.CreateScope()
+
+		svcs := scope.GetServices(reflect.TypeOf((*testRepository)(nil)).Elem())
+		assert.Len(t, svcs, 2)
+		assert.Equal(t, "sql", svcs[0].(testRepository).Get())
+		assert.Equal(t, "memory", svcs[1].(testRepository).Get())
+
+		// Scoped services should be reused within the scope.
+		repos := GetServices[testRepository](scope)
+		assert.Len(t, repos, 2)
+		assert.Equal(t, 1, builds)
+
+		// Singletons should be shared across scopes, unlike scoped services.
+		other := GetServices[testRepository](ctn.CreateScope())
+		assert.Same(t, svcs[0], other[0])
+		assert.Equal(t, 2, builds)
+	})
+
+	t.Run("Given Slice Dependency", func(t *testing.T) {
+		builds := 0
+		ctn := newContainer(&builds)
+		ctn.AddScoped(func(repos []testRepository) *testService {
+			return &testService{x: len(repos)}
+		})
+		scope := ctn.CreateScope()
+
+		v := GetService[*testService](scope)
+		assert.Equal(t, 2, v.x)
+		assert.Equal(t, 1, builds)
+		assert.Len(t, GetServices[testRepository](scope), 2)
+		assert.Equal(t, 1, builds)
+		assert.Empty(t, GetServices[*testDependency](scope))
+	})
+}
+
+func TestScope_Resolve(t *testing.T) {
+	type ctxKey struct{}
+
+	ctn := NewContainer()
+	ctn.AddScoped(func(ctx context.Context) *testService {
+		return &testService{x: ctx.Value(ctxKey{}).(int)}
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, 1)
+	var r Resolver = ctn.CreateScopeWithContext(ctx)
+	assert.Equal(t, ctx, r.Context())
+
+	v, err := r.Resolve(reflect.TypeOf(&testService{}))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v.(*testService).x)
+
+	v2, err := r.Resolve(reflect.TypeOf(&testService{}))
+	assert.NoError(t, err)
+	assert.Same(t, v, v2)
+}
+
+func TestScope_Resolve_Concurrent(t *testing.T) {
+	var builds atomic.Int32
+	ctn := NewContainer()
+	ctn.AddScoped(func() *testService {
+		builds.Add(1)
+		time.Sleep(time.Millisecond)
+		return &testService{}
+	})
+	scope := ctn.CreateScope()
+
+	var wg sync.WaitGroup
+	vs := make([]interface{}, 10)
+	for i := range vs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vs[i], _ = scope.Resolve(reflect.TypeOf(&testService{}))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), builds.Load())
+	for _, v := range vs {
+		assert.Same(t, vs[0], v)
+	}
+}
+
+func TestScope_Dispose(t *testing.T) {
+	disposed := make([]interface{}, 0)
+	dispose := func(ctx context.Context, i interface{}) {
+		disposed = append(disposed, i)
+	}
+
+	singleton := &testDependency2{}
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	}).AsScoped().SetDispose(dispose)
+	ctn.AddService(func(d *testDependency) *testService {
+		return &testService{dep: d}
+	}).AsScoped().SetDispose(dispose)
+	ctn.AddService(func() *testDependency2 {
+		return singleton
+	}).AsSingleton().SetDispose(dispose)
+
+	s := ctn.CreateScope()
+	svc := s.GetService("di.testService").(*testService)
+	_ = s.GetService("di.testDependency2")
+
+	s.Dispose(context.Background())
+
+	// Scoped services should be disposed, in reverse build order,
+	// whereas the singleton should not.
+	assert.Equal(t, []interface{}{svc, svc.dep}, disposed)
+
+	// Services should be rebuilt after disposal.
+	assert.NotSame(t, svc, s.GetService("di.testService"))
+}
+
+func TestScope_Reset(t *testing.T) {
+	type ctxKey struct{}
+
+	builds, disposed := 0, 0
+
+	ctn := NewContainer()
+	ctn.AddScoped(func(ctx context.Context) *testService {
+		builds++
+		return &testService{x: ctx.Value(ctxKey{}).(int)}
+	}).SetDispose(func(ctx context.Context, i interface{}) {
+		disposed++
+	})
+
+	s := ctn.CreateScopeWithContext(context.WithValue(context.Background(), ctxKey{}, 1))
+	v1 := s.GetService("di.testService").(*testService)
+	assert.Equal(t, 1, v1.x)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, 2)
+	assert.NoError(t, s.Reset(ctx))
+	assert.Equal(t, 1, disposed)
+	assert.Equal(t, ctx, s.Context())
+
+	// The scoped service should be built again, with the new context.
+	v2 := s.GetService("di.testService").(*testService)
+	assert.NotSame(t, v1, v2)
+	assert.Equal(t, 2, v2.x)
+	assert.Equal(t, 2, builds)
+	assert.Same(t, v2, s.GetService("di.testService"))
+}
+
+func TestScope_CreateScope(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, 42)
+
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency2 {
+		return &testDependency2{}
+	}).AsSingleton()
+	ctn.AddService(func(ctx context.Context, d *testDependency2) *testService {
+		return &testService{x: ctx.Value(ctxKey{}).(int)}
+	}).AsScoped()
+
+	parent := ctn.CreateScopeWithContext(ctx)
+	v1 := parent.GetService("di.testService").(*testService)
+
+	child := parent.CreateScope()
+	v2 := child.GetService("di.testService").(*testService)
+
+	// The child should have its own scoped services, with the parent's context.
+	assert.NotSame(t, v1, v2)
+	assert.Equal(t, 42, v2.x)
+	assert.Same(t, v2, child.GetService("di.testService"))
+	assert.Same(t, v1, parent.GetService("di.testService"))
+
+	// Singletons should still be shared.
+	assert.Same(t, parent.GetService("di.testDependency2"), child.GetService("di.testDependency2"))
+}
+
+func TestScope_WithAutoDispose(t *testing.T) {
+	t.Run("Where context is cancelled", func(t *testing.T) {
+		disposed := make(chan interface{}, 1)
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		}).AsScoped().SetDispose(func(ctx context.Context, i interface{}) {
+			disposed <- i
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s := ctn.CreateScopeWithContext(ctx, WithAutoDispose())
+		dep := s.GetService("di.testDependency")
+
+		cancel()
+
+		select {
+		case v := <-disposed:
+			assert.Same(t, dep, v)
+		case <-time.After(time.Second):
+			t.Fatal("scope was not disposed")
+		}
+	})
+
+	t.Run("Where scope is disposed explicitly", func(t *testing.T) {
+		disposed := make(chan interface{}, 2)
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		}).AsScoped().SetDispose(func(ctx context.Context, i interface{}) {
+			disposed <- i
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s := ctn.CreateScopeWithContext(ctx, WithAutoDispose())
+		_ = s.GetService("di.testDependency")
+
+		s.Dispose(context.Background())
+		assert.Len(t, disposed, 1)
+
+		// Services built after disposal should not be disposed on cancel.
+		_ = s.GetService("di.testDependency")
+		cancel()
+		assert.Never(t, func() bool {
+			return len(disposed) > 1
+		}, 50*time.Millisecond, 10*time.Millisecond)
+	})
+
+	t.Run("Where scope is closed", func(t *testing.T) {
+		disposed := make(chan interface{}, 1)
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		}).AsScoped().SetDispose(func(ctx context.Context, i interface{}) {
+			disposed <- i
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s := ctn.CreateScopeWithContext(ctx, WithAutoDispose())
+		_ = s.GetService("di.testDependency")
+
+		s.Close()
+		s.Close()
+		cancel()
+		assert.Never(t, func() bool {
+			return len(disposed) > 0
+		}, 50*time.Millisecond, 10*time.Millisecond)
+	})
+}
+
+func TestScopeFromContext(t *testing.T) {
+	s := NewContainer().CreateScope()
+
+	ctx := ContextWithScope(context.Background(), s)
+	v, ok := ScopeFromContext(ctx)
+	assert.True(t, ok)
+	assert.Same(t, s, v)
+
+	t.Run("Where context has no scope", func(t *testing.T) {
+		v, ok := ScopeFromContext(context.Background())
+		assert.False(t, ok)
+		assert.Nil(t, v)
+	})
+}
@@ -1,201 +1,888 @@
-package di
-
-import (
-	"context"
-	"fmt"
-	"reflect"
-	"sync"
-)
-
-// ServiceLifetime is a type used to define a service's lifetime.
-type ServiceLifetime uint
-
-const (
-	// LifetimeSingleton is used to define a Service as a singleton.
-	// Which means only a single instance of the Service will be built,
-	// then shared across other services.
-	LifetimeSingleton ServiceLifetime = iota
-
-	// LifetimeTransient is used to define a Service as transient. Which
-	// means a new instance will be instantiated each time the service is resolved.
-	LifetimeTransient
-
-	// LifetimeScoped is used to define a Service as scope. Which means
-	// a new instance is created for an individual scope, then re-used
-	// in that scope.
-	LifetimeScoped
-)
-
-// DisposeFunc is a function used to clean and dispose a singleton service.
-// The argument, i, is the instance of the service.
-type DisposeFunc func(ctx context.Context, i interface{})
-
-// Service represents a service within the DI Container. It contains
-// information on the type, lifetime and name of the service, as well
-// as, how to build it.
-type Service struct {
-	name     string
-	typ      reflect.Type
-	lifetime ServiceLifetime
-	ctor     interface{}
-	mu       sync.Mutex
-	impl     interface{}
-	dipsose  DisposeFunc
-}
-
-// NewService is used to create a new instance of Service. The ctor argument
-// should be the constructor function, which is used to build the service.
-//
-// A constructor function can contain an range of arguments, however, either
-// return an interface, or an interface and error: func() MyService or
-// func() (MyService, error).
-func NewService(ctor interface{}) *Service {
-	t := reflect.TypeOf(ctor)
-	if t.Kind() != reflect.Func {
-		panic(fmt.Errorf("service: %s is not a func", t.Name()))
-	}
-
-	switch t.NumOut() {
-	case 0:
-		panic(fmt.Errorf("service: %s should return a value", t.Name()))
-	case 1:
-		if isTypeError(t.Out(0)) {
-			panic(fmt.Errorf("service: %s should return a non-error value", t.Name()))
-		}
-	case 2:
-		if isTypeError(t.Out(0)) {
-			panic(fmt.Errorf("service: %s should return (interface{}, error)", t.Name()))
-		}
-
-		if !isTypeError(t.Out(1)) {
-			panic(fmt.Errorf("service: %s should return (interface{}, error)", t.Name()))
-		}
-	default:
-		panic(fmt.Errorf("service: %s can not contain more than 2 return values", t.Name()))
-	}
-
-	st := t.Out(0)
-	name := st.String()
-	if st.Kind() == reflect.Ptr {
-		name = st.Elem().String()
-	}
-
-	return &Service{
-		name:     name,
-		typ:      st,
-		lifetime: LifetimeTransient,
-		ctor:     ctor,
-		mu:       sync.Mutex{},
-	}
-}
-
-// This is used to determine whether a Type is an error or not.
-func isTypeError(t reflect.Type) bool {
-	err := reflect.TypeOf((*error)(nil)).Elem()
-	return t.Implements(err)
-}
-
-// SetName is used to set the name of the Service. Note that
-// this is can not be referred to in depedency injection, and
-// only when resolving a service through the Container.
-//
-// If name is empty, the name will not be updated and will remain
-// the name of the service interface.
-func (s *Service) SetName(name string) *Service {
-	if name != "" {
-		s.name = name
-	}
-
-	return s
-}
-
-// Name returns the name of the service. If this has not been manually
-// configured, the name of the service type will be returned.
-func (s *Service) Name() string {
-	return s.name
-}
-
-// SetDispose is used to configure a clean up/disposal function for a
-// service. This can be used to set a dispose function for a service with
-// any lifetime, however, will only be used for Singleton service.
-//
-// This is not required but is helpful for releasing resources consumed
-// by the service.
-func (s *Service) SetDispose(f DisposeFunc) *Service {
-	s.dipsose = f
-
-	return s
-}
-
-// Dispose is used to clean up singleton resources.
-func (s *Service) Dispose(ctx context.Context) {
-	if s.dipsose != nil {
-		s.dipsose(ctx, s.impl)
-	}
-
-	s.impl = nil
-}
-
-// AsSingleton sets the lifetime of the service to Singleton.
-func (s *Service) AsSingleton() *Service {
-	s.lifetime = LifetimeSingleton
-
-	return s
-}
-
-// AsTransient sets the lifetime of the service to Transient.
-func (s *Service) AsTransient() *Service {
-	s.lifetime = LifetimeTransient
-
-	return s
-}
-
-// AsScoped sets the lifetime of the service to Scoped.
-func (s *Service) AsScoped() *Service {
-	s.lifetime = LifetimeScoped
-
-	return s
-}
-
-// build is used to build a service as well as its dependency chain.
-func (s *Service) build(sp func(reflect.Type) (interface{}, error)) (interface{}, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// If the service is a singleton and already built, used the
-	// build instance instead of creating another.
-	if s.impl != nil && s.lifetime == LifetimeSingleton {
-		return s.impl, nil
-	}
-
-	f := reflect.ValueOf(s.ctor)
-	numIn := f.Type().NumIn()
-	args := make([]reflect.Value, numIn)
-
-	for i := 0; i < numIn; i++ {
-		arg := f.Type().In(i)
-		d, err := sp(arg)
-		if err != nil {
-			return nil, err
-		}
-
-		args[i] = reflect.ValueOf(d)
-	}
-
-	out := f.Call(args)
-	if len(out) == 2 {
-		err := out[1].Interface()
-		if err != nil {
-			return nil, err.(error)
-		}
-	}
-
-	impl := out[0].Interface()
-
-	// If the sevrice is a singleton, store the built instance in memory.
-	if s.lifetime == LifetimeSingleton {
-		s.impl = impl
-	}
-
-	return impl, nil
-}
+package di
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ServiceLifetime is a type used to define a service's lifetime.
+type ServiceLifetime uint
+
+const (
+	// LifetimeSingleton is used to define a Service as a singleton.
+	// Which means only a single instance of the Service will be built,
+	// then shared across other services.
+	LifetimeSingleton ServiceLifetime = iota
+
+	// LifetimeTransient is used to define a Service as transient. Which
+	// means a new instance will be instantiated each time the service is resolved.
+	// Within a single resolution, such as a call to GetService, the instance is
+	// shared by all services which depend on it, whereas factories called after
+	// the resolution is complete always build a new instance.
+	LifetimeTransient
+
+	// LifetimeScoped is used to define a Service as scope. Which means
+	// a new instance is created for an individual scope, then re-used
+	// in that scope. Scoped services can only be resolved from a Scope,
+	// otherwise ErrScopedOutsideScope is returned.
+	LifetimeScoped
+)
+
+// String returns the name of the lifetime.
+func (l ServiceLifetime) String() string {
+	switch l {
+	case LifetimeSingleton:
+		return "Singleton"
+	case LifetimeTransient:
+		return "Transient"
+	case LifetimeScoped:
+		return "Scoped"
+	default:
+		return fmt.Sprintf("ServiceLifetime(%d)", uint(l))
+	}
+}
+
+// DisposeFunc is a function used to clean and dispose a singleton service.
+// The argument, i, is the instance of the service.
+type DisposeFunc func(ctx context.Context, i interface{})
+
+// DisposeFuncE is a DisposeFunc which returns an error,
+// if the service could not be disposed.
+type DisposeFuncE func(ctx context.Context, i interface{}) error
+
+// InvokeFunc is a function used to invoke a service's constructor, ctor,
+// with the resolved arguments, args. It should return the constructor's
+// return values, or an error if the constructor could not be invoked.
+//
+// For a variadic constructor, the last of args is a slice of the variadic
+// parameter's values, as expected by reflect.Value.CallSlice.
+type InvokeFunc func(ctor interface{}, args []reflect.Value) ([]reflect.Value, error)
+
+// Service represents a service within the DI Container. It contains
+// information on the type, lifetime and name of the service, as well
+// as, how to build it.
+type Service struct {
+	name     string
+	typ      reflect.Type
+	lifetime ServiceLifetime
+	ctor     interface{}
+	mu       sync.Mutex
+	inst     atomic.Pointer[singleton]
+	dipsose  DisposeFunc
+	disposeE DisposeFuncE
+	groups   []string
+	key      string
+	invoker  InvokeFunc
+
+	// Decorators applied to the built service, in order.
+	decorators []*Service
+
+	// The container the service belongs to, if any.
+	ctn *Container
+
+	// Used to partition scoped instances within a Scope.
+	scopedBy func(context.Context) string
+
+	// Additional types the service can be resolved as.
+	ifaces []reflect.Type
+
+	// The number of resolves after which a transient service
+	// is promoted to a singleton, the current count, guarded
+	// by mu, and whether it has been promoted.
+	promoteAfter int
+	resolves     int
+	promoted     atomic.Bool
+
+	// Whether the service is resolved, where there
+	// are multiple services of the same type.
+	primary bool
+
+	// Additional names the service can be resolved by.
+	aliases []string
+
+	// The reflected constructor, which is cached on the
+	// first build, if it wasn't created by NewService.
+	sig atomic.Pointer[constructor]
+
+	// The services provided by the fields of an Out struct returned
+	// by the constructor, which are added to the container with it.
+	outputs []*Service
+
+	// The number of times the constructor is called, if it returns
+	// an error, and the delay between each attempt.
+	retryAttempts int
+	retryBackoff  time.Duration
+
+	// Called by Container.Start and Container.Stop, if not nil.
+	onStart func(ctx context.Context) error
+	onStop  func(ctx context.Context) error
+
+	// Used to order services of the same type, set by WithOrder.
+	order int
+
+	// Arbitrary metadata, set by SetMeta, guarded by mu.
+	meta map[string]interface{}
+
+	// Counts of the service's builds and cache hits.
+	stats serviceStats
+
+	// The maximum duration of the constructor, if positive.
+	timeout time.Duration
+
+	// The value of a service added by AddValue, which is
+	// resolved in place of calling a constructor.
+	value interface{}
+}
+
+// constructor holds the reflected details of a service's constructor, so
+// they don't need to be reflected each time the service is built.
+type constructor struct {
+	fn      reflect.Value
+	params  []param
+	withErr bool
+
+	// Whether the last parameter is variadic, in which case
+	// it is resolved as a slice, and passed using CallSlice.
+	variadic bool
+}
+
+// param is a parameter of a constructor.
+type param struct {
+	typ      reflect.Type
+	factory  bool
+	optional bool
+	provider bool
+
+	// Whether the parameter is a struct embedding In,
+	// in which case its fields are resolved individually.
+	in bool
+
+	// Whether the zero value is used, if the parameter can not be
+	// resolved, and the struct field it populates, if any.
+	zero  bool
+	field string
+
+	// The name of the service used to resolve the parameter, if any.
+	name string
+}
+
+// newConstructor reflects the constructor function ctor.
+func newConstructor(ctor interface{}) *constructor {
+	fn := reflect.ValueOf(ctor)
+	t := fn.Type()
+
+	params := make([]param, t.NumIn())
+	for i := range params {
+		pt := t.In(i)
+		params[i] = param{
+			typ:      pt,
+			factory:  isFactory(pt),
+			optional: isOptional(pt),
+			provider: isProvider(pt),
+			in:       isIn(pt),
+		}
+	}
+
+	return &constructor{
+		fn:       fn,
+		params:   params,
+		withErr:  t.NumOut() == 2,
+		variadic: t.IsVariadic(),
+	}
+}
+
+// NewService is used to create a new instance of Service. The ctor argument
+// should be the constructor function, which is used to build the service.
+//
+// A constructor function can contain an range of arguments, however, either
+// return an interface, or an interface and error: func() MyService or
+// func() (MyService, error).
+//
+// If the constructor returns a struct embedding Out, a service is also
+// created for each of its fields, as described by Out.
+func NewService(ctor interface{}) *Service {
+	t := reflect.TypeOf(ctor)
+	if t.Kind() != reflect.Func {
+		panic(fmt.Errorf("service: %s is not a func", t.Name()))
+	}
+
+	switch t.NumOut() {
+	case 0:
+		panic(fmt.Errorf("service: %s should return a value", t.Name()))
+	case 1:
+		if isTypeError(t.Out(0)) {
+			panic(fmt.Errorf("service: %s should return a non-error value", t.Name()))
+		}
+	case 2:
+		if isTypeError(t.Out(0)) {
+			panic(fmt.Errorf("service: %s should return (interface{}, error)", t.Name()))
+		}
+
+		if !isTypeError(t.Out(1)) {
+			panic(fmt.Errorf("service: %s should return (interface{}, error)", t.Name()))
+		}
+	default:
+		panic(fmt.Errorf("service: %s can not contain more than 2 return values", t.Name()))
+	}
+
+	st := t.Out(0)
+
+	s := &Service{
+		name:     serviceName(st),
+		typ:      st,
+		lifetime: LifetimeTransient,
+		ctor:     ctor,
+		mu:       sync.Mutex{},
+	}
+	s.sig.Store(newConstructor(ctor))
+
+	if isOut(st) {
+		s.outputs = outServices(st)
+		s.setLifetime(LifetimeSingleton)
+	}
+
+	return s
+}
+
+// constructor returns the service's reflected constructor.
+func (s *Service) constructor() *constructor {
+	if c := s.sig.Load(); c != nil {
+		return c
+	}
+
+	c := newConstructor(s.ctor)
+	s.sig.Store(c)
+	return c
+}
+
+// serviceName returns the default name of a service of type t. For
+// pointer types, the name of the element type is used.
+func serviceName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().String()
+	}
+
+	return t.String()
+}
+
+// This is used to determine whether a Type is an error or not.
+func isTypeError(t reflect.Type) bool {
+	err := reflect.TypeOf((*error)(nil)).Elem()
+	return t.Implements(err)
+}
+
+// SetName is used to set the name of the Service. Note that
+// this is can not be referred to in depedency injection, and
+// only when resolving a service through the Container.
+//
+// If name is empty, the name will not be updated and will remain
+// the name of the service interface. If the Service's Container
+// is in strict mode and the name is in use, SetName will panic.
+func (s *Service) SetName(name string) *Service {
+	if name == "" {
+		return s
+	}
+
+	if s.ctn != nil {
+		s.ctn.rename(s, name)
+	} else {
+		s.name = name
+	}
+
+	return s
+}
+
+// AddAlias is used to add an additional name, which the service can be
+// resolved by through the Container, in the same way as its name. The
+// alias refers to the same service, so singletons are not built again.
+//
+// If name is empty, no alias is added. If the Service's Container is
+// in strict mode and the name is in use, AddAlias will panic.
+func (s *Service) AddAlias(name string) *Service {
+	if name == "" {
+		return s
+	}
+
+	if s.ctn != nil {
+		s.ctn.alias(s, name)
+	} else {
+		s.aliases = append(s.aliases, name)
+	}
+
+	return s
+}
+
+// Name returns the name of the service. If this has not been manually
+// configured, the name of the service type will be returned.
+func (s *Service) Name() string {
+	return s.name
+}
+
+// SetDispose is used to configure a clean up/disposal function for a
+// service. This can be used to set a dispose function for a service with
+// any lifetime, however, will only be used for Singleton and Scoped services.
+//
+// This is not required but is helpful for releasing resources consumed
+// by the service.
+func (s *Service) SetDispose(f DisposeFunc) *Service {
+	s.dipsose = f
+	s.disposeE = nil
+
+	return s
+}
+
+// SetDisposeE is used to configure a clean up/disposal function for a
+// service, which can return an error. This replaces any function set
+// using SetDispose.
+func (s *Service) SetDisposeE(f DisposeFuncE) *Service {
+	s.disposeE = f
+	s.dipsose = nil
+
+	return s
+}
+
+// Dispose is used to clean up singleton resources. An error is returned
+// if the service's DisposeFuncE fails, or if ctx is already done, in which
+// case the instance is released without its DisposeFunc being called. If
+// the service hasn't been built, there is nothing to dispose, and nil is
+// returned.
+func (s *Service) Dispose(ctx context.Context) error {
+	impl, ok := s.release()
+	if !ok {
+		return nil
+	}
+
+	return s.disposeInstance(ctx, impl)
+}
+
+// release removes the service's singleton instance, if it has been
+// built, returning it so it can be disposed. Borrowed instances are
+// removed, but not returned, as they are disposed by their owner.
+func (s *Service) release() (interface{}, bool) {
+	if sg := s.inst.Swap(nil); sg != nil && sg.built.Load() && !sg.borrowed {
+		return sg.impl, true
+	}
+
+	return nil, false
+}
+
+// disposeTimeout is used to clean up the given instance of the service,
+// in the same way as disposeInstance, but returns ErrDisposeTimeout if it
+// takes longer than timeout. The DisposeFunc is given a context.Context
+// which is cancelled once the timeout has elapsed, and is left to return
+// in its own goroutine.
+func (s *Service) disposeTimeout(ctx context.Context, impl interface{}, timeout time.Duration) error {
+	if ctx.Err() != nil {
+		return s.disposeInstance(ctx, impl)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Buffered, so an abandoned dispose does not block when it returns.
+	done := make(chan error, 1)
+	go func() {
+		done <- s.disposeInstance(ctx, impl)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s after %s", ErrDisposeTimeout, s.Name(), timeout)
+	}
+}
+
+// disposeInstance is used to clean up the given instance of the service,
+// using the service's DisposeFunc or DisposeFuncE, if it has one. If ctx
+// is already done, the function isn't called, and an error wrapping both
+// ErrDisposeSkipped and the context's error is returned.
+func (s *Service) disposeInstance(ctx context.Context, impl interface{}) error {
+	if s.dipsose == nil && s.disposeE == nil {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w %s, %w", ErrDisposeSkipped, s.Name(), err)
+	}
+
+	if s.dipsose != nil {
+		s.dipsose(ctx, impl)
+	}
+
+	if s.disposeE != nil {
+		if err := s.disposeE(ctx, impl); err != nil {
+			return fmt.Errorf("service: failed to dispose %s, %w", s.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// As is used to make the service resolvable by the given interface types,
+// in addition to its own type. Each argument should be a nil pointer to
+// the interface, for example: (*Repository)(nil).
+//
+// If the service's type does not implement one of the interfaces, As will panic.
+func (s *Service) As(ifaces ...interface{}) *Service {
+	for _, i := range ifaces {
+		t := reflect.TypeOf(i)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+			panic(fmt.Errorf("service: %v is not a pointer to an interface", t))
+		}
+
+		t = t.Elem()
+		if !s.typ.Implements(t) {
+			panic(fmt.Errorf("service: %s does not implement %s", s.typ.String(), t.String()))
+		}
+
+		s.ifaces = append(s.ifaces, t)
+	}
+
+	s.reindex()
+
+	return s
+}
+
+// WithOrder is used to set the position of the service amongst the other
+// services of the same types or group, when they are retrieved together, such
+// as by GetServices, GetGroup or a slice dependency. Services are sorted by their order, then
+// the order in which they were registered. By default, the order is 0, so
+// services with a negative order come first.
+func (s *Service) WithOrder(n int) *Service {
+	s.order = n
+	s.reindex()
+
+	return s
+}
+
+// reindex is used to update the container's indexes, if the
+// service has been added to one, after its types or order change.
+func (s *Service) reindex() {
+	if s.ctn != nil {
+		s.ctn.reindex()
+	}
+}
+
+// WithKey is used to set the key of the service. Keyed services can be
+// injected using a Keyed dependency, which allows multiple services of
+// the same type to be distinguished from one another.
+func (s *Service) WithKey(key string) *Service {
+	s.key = key
+
+	return s
+}
+
+// AsPrimary marks the service as the primary service of its types. Where
+// multiple services can be resolved as the same type, the primary service
+// is resolved, rather than returning an ambiguity error. All the services
+// can still be retrieved, using GetServices.
+func (s *Service) AsPrimary() *Service {
+	s.primary = true
+
+	return s
+}
+
+// InGroup adds the service to the given groups. A group is an ordered
+// collection of services, which can be resolved together using GetGroup,
+// in the order they were registered.
+func (s *Service) InGroup(groups ...string) *Service {
+	s.groups = append(s.groups, groups...)
+
+	return s
+}
+
+// WithTag adds the given tags to the service. Tags are equivalent to groups,
+// so tagged services can be resolved using GetTagged, or GetGroup.
+func (s *Service) WithTag(tags ...string) *Service {
+	return s.InGroup(tags...)
+}
+
+// inGroup determines whether the service is a member of the given group.
+func (s *Service) inGroup(group string) bool {
+	for _, g := range s.groups {
+		if g == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AsSingleton sets the lifetime of the service to Singleton.
+func (s *Service) AsSingleton() *Service {
+	s.setLifetime(LifetimeSingleton)
+
+	return s
+}
+
+// AsTransient sets the lifetime of the service to Transient.
+func (s *Service) AsTransient() *Service {
+	s.setLifetime(LifetimeTransient)
+
+	return s
+}
+
+// AsScoped sets the lifetime of the service to Scoped.
+func (s *Service) AsScoped() *Service {
+	s.setLifetime(LifetimeScoped)
+
+	return s
+}
+
+// setLifetime sets the lifetime of the service, and
+// of any services provided by its Out struct.
+func (s *Service) setLifetime(l ServiceLifetime) {
+	s.lifetime = l
+	for _, o := range s.outputs {
+		o.lifetime = l
+	}
+}
+
+// isTransient determines whether the service currently has a Transient
+// lifetime, which may change if it's promoted to a Singleton.
+func (s *Service) isTransient() bool {
+	return s.currentLifetime() == LifetimeTransient
+}
+
+// currentLifetime returns the lifetime of the service, which is Singleton
+// once a transient service has been promoted by PromoteToSingletonAfter.
+// Promotion doesn't change the lifetime field, so it's safe to read while
+// the service is being resolved concurrently.
+func (s *Service) currentLifetime() ServiceLifetime {
+	if s.promoted.Load() {
+		return LifetimeSingleton
+	}
+
+	return s.lifetime
+}
+
+// WithRetry configures the service's constructor to be called again, if it
+// returns an error, up to a total of attempts times, waiting for backoff
+// between each attempt. This is intended for constructors which may fail
+// temporarily, such as when connecting to a network service.
+//
+// Dependencies are resolved once, and only errors returned by the constructor
+// are retried, rather than panics or errors resolving its dependencies. If
+// every attempt fails, the error returned wraps that of the last attempt. The
+// wait is abandoned if the resolution's context.Context is done.
+func (s *Service) WithRetry(attempts int, backoff time.Duration) *Service {
+	s.retryAttempts = attempts
+	s.retryBackoff = backoff
+
+	return s
+}
+
+// SetInvoker is used to configure how the service's constructor is invoked,
+// overriding the default behaviour of calling it directly. This can be used
+// to wrap the constructor call, for example, to recover panics, retry or
+// add instrumentation specific to this service.
+func (s *Service) SetInvoker(f InvokeFunc) *Service {
+	s.invoker = f
+
+	return s
+}
+
+// PromoteToSingletonAfter is used to promote a transient service to a
+// singleton once it has been resolved n times. The instance built on the
+// next resolve is then cached and shared, as with any other singleton.
+//
+// This is intended for services which turn out to be effectively immutable,
+// where building a new instance on each resolve is unnecessary.
+func (s *Service) PromoteToSingletonAfter(n int) *Service {
+	s.promoteAfter = n
+
+	return s
+}
+
+// AsScopedBy sets the lifetime of the service to Scoped, where instances
+// within a Scope are further partitioned by the value returned by f for the
+// resolution's context.Context. For example, f may return a trace ID, so that
+// a Scope holds one instance per trace.
+func (s *Service) AsScopedBy(f func(ctx context.Context) string) *Service {
+	s.setLifetime(LifetimeScoped)
+	s.scopedBy = f
+	for _, o := range s.outputs {
+		o.scopedBy = f
+	}
+
+	return s
+}
+
+// discriminator returns the value used to partition the
+// service's scoped instances, for the given context.
+func (s *Service) discriminator(ctx context.Context) string {
+	if s.scopedBy == nil {
+		return ""
+	}
+
+	return s.scopedBy(ctx)
+}
+
+// dependencyNames returns the names of the types of the
+// service's dependencies, the constructor's parameters.
+func (s *Service) dependencyNames() []string {
+	params := s.constructor().params
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.typ.String()
+	}
+
+	return names
+}
+
+// build is used to build a service as well as its dependency chain.
+func (s *Service) build(r resolver, depth int) (interface{}, error) {
+	// If the service is a singleton and already built, used the
+	// build instance instead of creating another.
+	if sg := s.inst.Load(); sg != nil && sg.built.Load() {
+		return sg.impl, nil
+	}
+
+	if s.promoteAfter > 0 && !s.promoted.Load() && s.lifetime == LifetimeTransient {
+		s.mu.Lock()
+		s.resolves++
+		if s.resolves > s.promoteAfter {
+			s.promoted.Store(true)
+		}
+		s.mu.Unlock()
+	}
+	lifetime := s.currentLifetime()
+
+	if lifetime == LifetimeSingleton {
+		return s.buildSingleton(func() (interface{}, error) {
+			return s.traced(lifetime, depth, r)
+		})
+	}
+
+	return s.traced(lifetime, depth, r)
+}
+
+// resolved is used to call the OnResolve callback of the service's
+// Container, if it has one, where cached is whether an existing
+// instance of the service is used.
+func (s *Service) resolved(cached bool) {
+	if cached {
+		s.stats.hits.Add(1)
+	}
+
+	if s.ctn == nil || s.ctn.onResolve == nil {
+		return
+	}
+
+	s.ctn.onResolve(s.Name(), s.currentLifetime(), cached)
+}
+
+// traced is used to construct a new instance of the service, logging the
+// construction and calling the build hook, if the service's Container has
+// either configured. The depth is the number of services in the chain
+// being built, which this service is a dependency of.
+func (s *Service) traced(lifetime ServiceLifetime, depth int, r resolver) (interface{}, error) {
+	if s.ctn == nil || (s.ctn.logger == nil && s.ctn.onBuild == nil) {
+		return s.construct(r)
+	}
+
+	var done func(err error)
+	if s.ctn.onBuild != nil {
+		// The resolver provides the context of the resolution,
+		// which is the Scope's, when building within a Scope.
+		done = s.ctn.onBuild(r.Context(), s.Name())
+	}
+
+	start := time.Now()
+	impl, err := s.construct(r)
+
+	if done != nil {
+		done(err)
+	}
+
+	if s.ctn.logger != nil {
+		s.ctn.logger.Debug("service built",
+			slog.String("name", s.Name()),
+			slog.String("lifetime", lifetime.String()),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("depth", depth),
+			slog.Bool("ok", err == nil))
+	}
+
+	return impl, err
+}
+
+// construct is used to build a new instance of the service, then apply
+// its decorators. If the resolution's context.Context is done, the
+// constructor isn't called, and the context's error is returned.
+func (s *Service) construct(r resolver) (interface{}, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, fmt.Errorf("service: %s was not built, %w", s.Name(), err)
+	}
+
+	// Values added by AddValue are used in place of a constructor.
+	var (
+		impl = s.value
+		err  error
+	)
+	if impl == nil {
+		impl, err = s.callTimeout(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.stats.built()
+
+	for _, d := range s.decorators {
+		impl, err = d.call(r, valueOf(impl, s.typ))
+		if err != nil {
+			return nil, fmt.Errorf("service: failed to decorate %s, %w", s.Name(), err)
+		}
+	}
+
+	return impl, nil
+}
+
+// call is used to invoke the service's constructor, where the leading
+// arguments are given by args and the remaining are resolved using r.
+func (s *Service) call(r resolver, args ...reflect.Value) (interface{}, error) {
+	c := s.constructor()
+	args, err := resolveArgs(r, c.params, args, s.typ)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.callResolved(r.Context(), c, args)
+}
+
+// callResolved is used to invoke the constructor c with the resolved
+// arguments, args, retrying as configured by WithRetry, where ctx is
+// the context.Context of the resolution.
+func (s *Service) callResolved(ctx context.Context, c *constructor, args []reflect.Value) (interface{}, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := s.invoke(c, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.withErr || out[1].IsNil() {
+			return out[0].Interface(), nil
+		}
+
+		err = out[1].Interface().(error)
+		if attempt >= s.retryAttempts {
+			if attempt > 1 {
+				return nil, fmt.Errorf("service: failed to construct %s after %d attempts, %w", s.Name(), attempt, err)
+			}
+
+			return nil, err
+		}
+
+		select {
+		case <-time.After(s.retryBackoff):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("service: failed to construct %s after %d attempts, %w, %w", s.Name(), attempt, err, ctx.Err())
+		}
+	}
+}
+
+// resolveArgs is used to resolve the arguments for params, using r, where
+// the leading arguments are given by args. The type typ is that of the
+// service being built, used to describe errors.
+func resolveArgs(r resolver, params []param, args []reflect.Value, typ reflect.Type) ([]reflect.Value, error) {
+	if len(args) < len(params) {
+		args = append(make([]reflect.Value, 0, len(params)), args...)
+	}
+
+	for _, p := range params[len(args):] {
+		if p.factory {
+			args = append(args, makeFactory(p.typ, r))
+			continue
+		}
+
+		if p.provider {
+			args = append(args, makeProvider(p.typ, r))
+			continue
+		}
+
+		if p.optional {
+			v, err := resolveOptional(p.typ, r)
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, v)
+			continue
+		}
+
+		if p.in {
+			v, err := resolveIn(p.typ, r)
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, v)
+			continue
+		}
+
+		var (
+			d   interface{}
+			err error
+		)
+
+		if p.name != "" {
+			d, err = r.resolveNamed(p.name)
+		} else {
+			d, err = r.Resolve(p.typ)
+		}
+
+		switch {
+		case err != nil && p.zero && isNotFound(err):
+			args = append(args, reflect.Zero(p.typ))
+			continue
+		case err != nil && p.field != "":
+			return nil, fmt.Errorf("service: failed to resolve field %s of %s, %w", p.field, typ.String(), err)
+		case err != nil:
+			return nil, err
+		case d != nil && !reflect.TypeOf(d).AssignableTo(p.typ):
+			return nil, fmt.Errorf("service: %s is not assignable to %s", reflect.TypeOf(d).String(), p.typ.String())
+		}
+
+		args = append(args, valueOf(d, p.typ))
+	}
+
+	return args, nil
+}
+
+// invoke is used to call the constructor, c, with the given args. Unless
+// disabled by the container, a panic raised by the constructor is recovered
+// and returned as an error, which wraps the panic's value if it's an error.
+func (s *Service) invoke(c *constructor, args []reflect.Value) (out []reflect.Value, err error) {
+	if s.invoker != nil {
+		return s.invoker(s.ctor, args)
+	}
+
+	call := c.fn.Call
+	if c.variadic {
+		call = c.fn.CallSlice
+	}
+
+	if s.ctn != nil && s.ctn.noRecover {
+		return call(args), nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if re, ok := r.(error); ok {
+				err = fmt.Errorf("service: %s panicked, %w", s.Name(), re)
+				return
+			}
+
+			err = fmt.Errorf("service: %s panicked, %v", s.Name(), r)
+		}
+	}()
+
+	return call(args), nil
+}
@@ -1,7 +1,57 @@
 package di
 
+import "reflect"
+
 // ServiceProvider is an interface used to get a service
 // from a container.
+//
+// A constructor can depend on a ServiceProvider, or *Container, without
+// it being registered, in which case it's given the Container, or Scope,
+// resolving it. This is intended for factories, which resolve services
+// dynamically, by name. Otherwise, services should depend on what they
+// use directly, so their dependencies are known to the container, and
+// can be validated.
+//
+// A Scope given to a constructor should not be used until the constructor
+// has returned, as the Scope is locked while its services are built.
 type ServiceProvider interface {
 	GetService(name string) interface{}
 }
+
+var (
+	containerType       = reflect.TypeOf((*Container)(nil))
+	serviceProviderType = reflect.TypeOf((*ServiceProvider)(nil)).Elem()
+	scopeType           = reflect.TypeOf((*Scope)(nil))
+)
+
+// isContainerType determines whether t is *Container or ServiceProvider,
+// which can be injected without being registered.
+func isContainerType(t reflect.Type) bool {
+	return t == containerType || t == serviceProviderType
+}
+
+// GroupProvider is an interface used to get the services
+// in a group from a container.
+type GroupProvider interface {
+	ServiceProvider
+	GetGroup(group string) []interface{}
+}
+
+// KeyedProvider is an interface used to get a service of a
+// type, registered with a key, from a container.
+type KeyedProvider interface {
+	ServiceProvider
+	GetKeyedService(t reflect.Type, key string) interface{}
+}
+
+// typedProvider is implemented by service providers which can
+// resolve a service by its type, in the same way as GetService.
+type typedProvider interface {
+	getServiceByType(t reflect.Type) interface{}
+}
+
+// servicesProvider is implemented by service providers which can
+// resolve each of the services of a type.
+type servicesProvider interface {
+	GetServices(t reflect.Type) []interface{}
+}
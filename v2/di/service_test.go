@@ -1,334 +1,739 @@
-package di
-
-import (
-	"context"
-	"math/rand"
-	"reflect"
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-)
-
-type TestService interface{}
-
-type testService struct {
-	dep *testDependency
-	x   int
-}
-
-type testDependency struct{}
-
-// With no imagination, this is just another test dependency.
-type testDependency2 struct{}
-
-func TestNewService_GivenValidCtorFunc_ReturnsService(t *testing.T) {
-	t.Run("Where Return Value Is Interface", func(t *testing.T) {
-		f := func() TestService {
-			return &testService{}
-		}
-
-		s := NewService(f)
-		assert.Equal(t, "di.TestService", s.name)
-		assert.Equal(t, LifetimeTransient, s.lifetime)
-
-		_, ok := reflect.New(s.typ).Interface().(*TestService)
-		assert.True(t, ok)
-	})
-
-	t.Run("Where Return Value Is Ptr", func(t *testing.T) {
-		f := func() *testService {
-			return &testService{}
-		}
-
-		s := NewService(f)
-		assert.Equal(t, "di.testService", s.name)
-		assert.Equal(t, LifetimeTransient, s.lifetime)
-
-		_, ok := reflect.New(s.typ).Elem().Interface().(*testService)
-		assert.True(t, ok)
-	})
-}
-
-func TestNewService_GivenInvalidCtorFunc_Panics(t *testing.T) {
-	assert.Panics(t, func() {
-		// f does is not valid because a func cannot only return an error.
-		f := func() error {
-			return nil
-		}
-
-		_ = NewService(f)
-	})
-
-	assert.Panics(t, func() {
-		// f does is not valid because a func should return
-		// an error value last.
-		f := func() (error, interface{}) {
-			return nil, nil
-		}
-
-		_ = NewService(f)
-	})
-
-	assert.Panics(t, func() {
-		// f does is not valid because a func should only
-		// return a single interface value.
-		f := func() (interface{}, interface{}) {
-			return nil, nil
-		}
-
-		_ = NewService(f)
-	})
-
-	assert.Panics(t, func() {
-		// f does is not valid because a func should only
-		// return between 1 and 2 values.
-		f := func() (interface{}, interface{}, error) {
-			return nil, nil, nil
-		}
-
-		_ = NewService(f)
-	})
-
-	assert.Panics(t, func() {
-		// f does is not valid because a func should return a value.
-		f := func() {}
-
-		_ = NewService(f)
-	})
-
-	assert.Panics(t, func() {
-		// f is not a func value.
-		f := "this is not a func"
-
-		_ = NewService(f)
-	})
-}
-
-func TestService_SetName(t *testing.T) {
-	t.Run("Given Valid Name", func(t *testing.T) {
-		s := &Service{}
-		name := "MyService"
-		s.SetName(name)
-		assert.Equal(t, name, s.name)
-	})
-
-	t.Run("Given Empty Name", func(t *testing.T) {
-		name := "MyService"
-		s := &Service{name: name}
-
-		// Does not set name.
-		s.SetName("")
-		assert.Equal(t, name, s.name)
-	})
-}
-
-func TestService_Name(t *testing.T) {
-	name := "MyService"
-	s := &Service{name: name}
-	assert.Equal(t, name, s.Name())
-}
-
-func TestService_SetDispose(t *testing.T) {
-	s := &Service{}
-	s.SetDispose(func(ctx context.Context, i interface{}) {
-		// empty dispose func
-	})
-
-	assert.NotNil(t, s.dipsose)
-}
-
-func TestService_Dispose(t *testing.T) {
-	t.Run("Where Dispose Has Been Set", func(t *testing.T) {
-		called := false
-		instance := "some service"
-		s := &Service{impl: instance}
-		s.SetDispose(func(ctx context.Context, i interface{}) {
-			assert.Equal(t, instance, i)
-			called = true
-		})
-
-		s.Dispose(context.Background())
-		assert.True(t, called)
-		assert.Nil(t, s.impl)
-	})
-
-	t.Run("Where Dispose Has Not Been Set", func(t *testing.T) {
-		s := &Service{}
-
-		s.Dispose(context.Background())
-		assert.Nil(t, s.impl)
-	})
-}
-
-func TestService_AsSingleton(t *testing.T) {
-	s := &Service{lifetime: LifetimeTransient}
-	s.AsSingleton()
-
-	assert.Equal(t, LifetimeSingleton, s.lifetime)
-}
-
-func TestService_AsTransient(t *testing.T) {
-	s := &Service{lifetime: LifetimeSingleton}
-	s.AsTransient()
-
-	assert.Equal(t, LifetimeTransient, s.lifetime)
-}
-
-func TestService_Build(t *testing.T) {
-	t.Run("Given Transient Service", func(t *testing.T) {
-		ctn := NewContainer()
-		ctor := func() (*testService, error) {
-			return &testService{
-				x: rand.Int(),
-			}, nil
-		}
-		s := &Service{
-			ctor:     ctor,
-			typ:      reflect.TypeOf(&testService{}),
-			lifetime: LifetimeTransient,
-		}
-
-		v1, err := s.build(ctn.getService)
-		assert.NotNil(t, v1)
-		assert.Nil(t, err)
-
-		v2, err := s.build(ctn.getService)
-		assert.NotNil(t, v2)
-		assert.Nil(t, err)
-
-		// Assert that the two builds are different
-		// as the service is transient.
-		assert.NotSame(t, v1, v2)
-	})
-
-	t.Run("Given Singleton Service", func(t *testing.T) {
-		ctn := NewContainer()
-		ctor := func() (*testService, error) {
-			return &testService{
-				x: rand.Int(),
-			}, nil
-		}
-		s := &Service{
-			ctor:     ctor,
-			typ:      reflect.TypeOf(&testService{}),
-			lifetime: LifetimeSingleton,
-		}
-
-		v1, err := s.build(ctn.getService)
-		assert.NotNil(t, v1)
-		assert.Nil(t, err)
-
-		v2, err := s.build(ctn.getService)
-		assert.NotNil(t, v2)
-		assert.Nil(t, err)
-
-		// Assert that the two builds are the same
-		// as the service is a singleton.
-		assert.Same(t, v1, v2)
-	})
-
-	t.Run("Where Ctor Returns Error", func(t *testing.T) {
-		ctn := NewContainer()
-		ctor := func() (*testService, error) {
-			return nil, assert.AnError
-		}
-		s := &Service{
-			ctor: ctor,
-			typ:  reflect.TypeOf(&testService{}),
-		}
-
-		v1, err := s.build(ctn.getService)
-		assert.Nil(t, v1)
-		assert.Equal(t, assert.AnError, err)
-	})
-
-	t.Run("Where Service Has Dependency", func(t *testing.T) {
-		ctn := NewContainer()
-		ctn.services = []*Service{
-			{
-				name:     "di.testDependency",
-				typ:      reflect.TypeOf(&testDependency{}),
-				lifetime: LifetimeTransient,
-				ctor: func() *testDependency {
-					return &testDependency{}
-				},
-			},
-		}
-		ctor := func(d *testDependency) (*testService, error) {
-			return &testService{
-				dep: d,
-				x:   rand.Int(),
-			}, nil
-		}
-		s := &Service{
-			ctor:     ctor,
-			typ:      reflect.TypeOf(&testService{}),
-			lifetime: LifetimeSingleton,
-		}
-
-		v, err := s.build(ctn.getService)
-		assert.NotNil(t, v)
-		assert.Nil(t, err)
-
-		ts := v.(*testService)
-		assert.NotNil(t, ts.dep)
-	})
-
-	t.Run("Where Service Cannot Find Dependency", func(t *testing.T) {
-		ctn := NewContainer()
-		ctn.services = []*Service{
-			{
-				name:     "di.testDependency",
-				typ:      reflect.TypeOf(&testDependency{}),
-				lifetime: LifetimeTransient,
-				ctor: func() *testDependency {
-					return &testDependency{}
-				},
-			},
-		}
-		ctor := func(d *testDependency, d2 *testDependency2) (*testService, error) {
-			return &testService{
-				dep: d,
-				x:   rand.Int(),
-			}, nil
-		}
-		s := &Service{
-			ctor:     ctor,
-			typ:      reflect.TypeOf(&testService{}),
-			lifetime: LifetimeSingleton,
-		}
-
-		v, err := s.build(ctn.getService)
-		assert.Nil(t, v)
-		assert.NotNil(t, err)
-	})
-
-	t.Run("Where Service Dependency Failed To Build", func(t *testing.T) {
-		ctn := NewContainer()
-		ctn.services = []*Service{
-			{
-				name:     "di.testDependency",
-				typ:      reflect.TypeOf(&testDependency{}),
-				lifetime: LifetimeTransient,
-				ctor: func() (*testDependency, error) {
-					return nil, assert.AnError
-				},
-			},
-		}
-		ctor := func(d *testDependency, d2 *testDependency2) (*testService, error) {
-			return &testService{
-				dep: d,
-				x:   rand.Int(),
-			}, nil
-		}
-		s := &Service{
-			ctor:     ctor,
-			typ:      reflect.TypeOf(&testService{}),
-			lifetime: LifetimeSingleton,
-		}
-
-		v, err := s.build(ctn.getService)
-		assert.Nil(t, v)
-		assert.Contains(t, err.Error(), assert.AnError.Error())
-	})
-}
+package di
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestService interface{}
+
+type testService struct {
+	dep *testDependency
+	x   int
+}
+
+type testDependency struct{}
+
+// With no imagination, this is just another test dependency.
+type testDependency2 struct{}
+
+func TestNewService_GivenValidCtorFunc_ReturnsService(t *testing.T) {
+	t.Run("Where Return Value Is Interface", func(t *testing.T) {
+		f := func() TestService {
+			return &testService{}
+		}
+
+		s := NewService(f)
+		assert.Equal(t, "di.TestService", s.name)
+		assert.Equal(t, LifetimeTransient, s.lifetime)
+
+		_, ok := reflect.New(s.typ).Interface().(*TestService)
+		assert.True(t, ok)
+	})
+
+	t.Run("Where Return Value Is Ptr", func(t *testing.T) {
+		f := func() *testService {
+			return &testService{}
+		}
+
+		s := NewService(f)
+		assert.Equal(t, "di.testService", s.name)
+		assert.Equal(t, LifetimeTransient, s.lifetime)
+
+		_, ok := reflect.New(s.typ).Elem().Interface().(*testService)
+		assert.True(t, ok)
+	})
+}
+
+func TestNewService_GivenInvalidCtorFunc_Panics(t *testing.T) {
+	assert.Panics(t, func() {
+		// f does is not valid because a func cannot only return an error.
+		f := func() error {
+			return nil
+		}
+
+		_ = NewService(f)
+	})
+
+	assert.Panics(t, func() {
+		// f does is not valid because a func should return
+		// an error value last.
+		f := func() (error, interface{}) {
+			return nil, nil
+		}
+
+		_ = NewService(f)
+	})
+
+	assert.Panics(t, func() {
+		// f does is not valid because a func should only
+		// return a single interface value.
+		f := func() (interface{}, interface{}) {
+			return nil, nil
+		}
+
+		_ = NewService(f)
+	})
+
+	assert.Panics(t, func() {
+		// f does is not valid because a func should only
+		// return between 1 and 2 values.
+		f := func() (interface{}, interface{}, error) {
+			return nil, nil, nil
+		}
+
+		_ = NewService(f)
+	})
+
+	assert.Panics(t, func() {
+		// f does is not valid because a func should return a value.
+		f := func() {}
+
+		_ = NewService(f)
+	})
+
+	assert.Panics(t, func() {
+		// f is not a func value.
+		f := "this is not a func"
+
+		_ = NewService(f)
+	})
+}
+
+func TestService_SetName(t *testing.T) {
+	t.Run("Given Valid Name", func(t *testing.T) {
+		s := &Service{}
+		name := "MyService"
+		s.SetName(name)
+		assert.Equal(t, name, s.name)
+	})
+
+	t.Run("Given Empty Name", func(t *testing.T) {
+		name := "MyService"
+		s := &Service{name: name}
+
+		// Does not set name.
+		s.SetName("")
+		assert.Equal(t, name, s.name)
+	})
+
+	t.Run("Given Name In Use In Strict Mode", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetStrict(true)
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		}).AddAlias("db")
+		s := ctn.AddService(func() *testService {
+			return &testService{}
+		})
+
+		assert.PanicsWithError(t, "container: can not name *di.testService db, as the name is used by *di.testDependency", func() {
+			s.SetName("db")
+		})
+		assert.Equal(t, "di.testService", s.Name())
+		assert.IsType(t, &testDependency{}, ctn.GetService("db"))
+	})
+
+	t.Run("Given Name In Use", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		}).SetName("db")
+		s := ctn.AddService(func() *testService {
+			return &testService{}
+		}).SetName("db")
+
+		assert.Equal(t, "db", s.Name())
+		assert.IsType(t, &testService{}, ctn.GetService("db"))
+	})
+}
+
+func TestService_AddAlias(t *testing.T) {
+	t.Run("Given Registered Service", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			return &testService{}
+		}).AsSingleton().SetName("db").AddAlias("primaryDB")
+
+		assert.Same(t, ctn.GetService("db"), ctn.GetService("primaryDB"))
+	})
+
+	t.Run("Given Empty Name", func(t *testing.T) {
+		s := &Service{}
+
+		// Does not add alias.
+		s.AddAlias("")
+		assert.Empty(t, s.aliases)
+	})
+
+	t.Run("Given Name In Use In Strict Mode", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.SetStrict(true)
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		})
+		s := ctn.AddService(func() *testService {
+			return &testService{}
+		})
+
+		assert.Panics(t, func() {
+			s.AddAlias("di.testDependency")
+		})
+	})
+}
+
+func TestService_Name(t *testing.T) {
+	name := "MyService"
+	s := &Service{name: name}
+	assert.Equal(t, name, s.Name())
+}
+
+func TestService_SetDispose(t *testing.T) {
+	s := &Service{}
+	s.SetDispose(func(ctx context.Context, i interface{}) {
+		// empty dispose func
+	})
+
+	assert.NotNil(t, s.dipsose)
+}
+
+func TestService_SetDisposeE(t *testing.T) {
+	s := &Service{}
+	s.SetDispose(func(ctx context.Context, i interface{}) {})
+	s.SetDisposeE(func(ctx context.Context, i interface{}) error {
+		return nil
+	})
+
+	assert.NotNil(t, s.disposeE)
+	assert.Nil(t, s.dipsose)
+}
+
+func TestService_Dispose(t *testing.T) {
+	t.Run("Where Dispose Has Been Set", func(t *testing.T) {
+		called := false
+		instance := "some service"
+		s := &Service{}
+		s.setInstance(instance)
+		s.SetDispose(func(ctx context.Context, i interface{}) {
+			assert.Equal(t, instance, i)
+			called = true
+		})
+
+		s.Dispose(context.Background())
+		assert.True(t, called)
+		assert.Nil(t, s.instance())
+	})
+
+	t.Run("Where DisposeE Fails", func(t *testing.T) {
+		s := &Service{name: "MyService"}
+		s.setInstance("some service")
+		s.SetDisposeE(func(ctx context.Context, i interface{}) error {
+			return assert.AnError
+		})
+
+		err := s.Dispose(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "MyService")
+		assert.Nil(t, s.instance())
+	})
+
+	t.Run("Where Dispose Has Not Been Set", func(t *testing.T) {
+		s := &Service{}
+
+		s.Dispose(context.Background())
+		assert.Nil(t, s.instance())
+	})
+
+	t.Run("Where Context Is Cancelled", func(t *testing.T) {
+		called := false
+		s := &Service{name: "MyService"}
+		s.setInstance("some service")
+		s.SetDispose(func(ctx context.Context, i interface{}) {
+			called = true
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := s.Dispose(ctx)
+		assert.ErrorIs(t, err, ErrDisposeSkipped)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.EqualError(t, err, "service: skipped disposing MyService, context canceled")
+		assert.False(t, called)
+		assert.Nil(t, s.instance())
+	})
+
+	t.Run("Where Service Has Not Been Built", func(t *testing.T) {
+		called := false
+		s := &Service{name: "MyService"}
+		s.SetDispose(func(ctx context.Context, i interface{}) {
+			called = true
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.NoError(t, s.Dispose(ctx))
+		assert.NoError(t, s.Dispose(context.Background()))
+		assert.False(t, called)
+	})
+}
+
+func TestService_As(t *testing.T) {
+	t.Run("Given Implemented Interface", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testSQLRepository {
+			return &testSQLRepository{}
+		}).As((*testRepository)(nil))
+
+		v, err := ctn.Resolve(reflect.TypeOf((*testRepository)(nil)).Elem())
+		assert.Nil(t, err)
+		assert.IsType(t, &testSQLRepository{}, v)
+
+		// Can still be resolved by its own type.
+		v, err = ctn.Resolve(reflect.TypeOf(&testSQLRepository{}))
+		assert.Nil(t, err)
+		assert.NotNil(t, v)
+	})
+
+	t.Run("Given Unimplemented Interface", func(t *testing.T) {
+		s := NewService(func() *testDependency {
+			return &testDependency{}
+		})
+
+		assert.Panics(t, func() {
+			s.As((*testRepository)(nil))
+		})
+	})
+
+	t.Run("Given Non Interface Pointer", func(t *testing.T) {
+		s := NewService(func() *testDependency {
+			return &testDependency{}
+		})
+
+		assert.Panics(t, func() {
+			s.As(&testDependency{})
+		})
+	})
+}
+
+func TestService_AsSingleton(t *testing.T) {
+	s := &Service{lifetime: LifetimeTransient}
+	s.AsSingleton()
+
+	assert.Equal(t, LifetimeSingleton, s.lifetime)
+}
+
+func TestService_AsTransient(t *testing.T) {
+	s := &Service{lifetime: LifetimeSingleton}
+	s.AsTransient()
+
+	assert.Equal(t, LifetimeTransient, s.lifetime)
+}
+
+func TestService_SetInvoker(t *testing.T) {
+	recoverInvoker := func(ctor interface{}, args []reflect.Value) (out []reflect.Value, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("recovered: %v", r)
+			}
+		}()
+
+		return reflect.ValueOf(ctor).Call(args), nil
+	}
+
+	t.Run("Where Ctor Panics", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddService(func() *testService {
+			panic("something went wrong")
+		}).SetInvoker(recoverInvoker)
+
+		v, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.Nil(t, v)
+		assert.EqualError(t, err, "recovered: something went wrong")
+	})
+
+	t.Run("Where Ctor Succeeds", func(t *testing.T) {
+		ctn := NewContainer()
+		s := ctn.AddService(func() *testService {
+			return &testService{}
+		}).SetInvoker(recoverInvoker)
+
+		v, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.NotNil(t, v)
+		assert.Nil(t, err)
+	})
+}
+
+func TestService_WithRetry(t *testing.T) {
+	t.Run("Where Constructor Fails Twice", func(t *testing.T) {
+		calls := 0
+
+		ctn := NewContainer()
+		ctn.AddService(func() (*testService, error) {
+			calls++
+			if calls < 3 {
+				return nil, assert.AnError
+			}
+			return &testService{x: calls}, nil
+		}).AsSingleton().WithRetry(3, time.Millisecond)
+
+		v := ctn.GetService("di.testService").(*testService)
+		assert.Equal(t, 3, v.x)
+
+		// Should not construct the singleton again.
+		assert.Same(t, v, ctn.GetService("di.testService"))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Where Every Attempt Fails", func(t *testing.T) {
+		calls := 0
+
+		ctn := NewContainer()
+		ctn.AddService(func() (*testService, error) {
+			calls++
+			return nil, assert.AnError
+		}).WithRetry(2, time.Millisecond)
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "service: failed to construct di.testService after 2 attempts")
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Where Context Is Done", func(t *testing.T) {
+		calls := 0
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ctn := NewContainer(WithContext(ctx))
+		ctn.AddService(func() (*testService, error) {
+			calls++
+			cancel()
+			return nil, assert.AnError
+		}).WithRetry(3, time.Minute)
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestService_PromoteToSingletonAfter(t *testing.T) {
+	ctn := NewContainer()
+	s := ctn.AddService(func() *testService {
+		return &testService{x: rand.Int()}
+	}).PromoteToSingletonAfter(2)
+
+	v1, _ := s.build(ctn.resolver(ctn.ctx, nil), 0)
+	v2, _ := s.build(ctn.resolver(ctn.ctx, nil), 0)
+	assert.NotSame(t, v1, v2)
+	assert.Equal(t, LifetimeTransient, s.currentLifetime())
+
+	// Resolves past the threshold should be identical.
+	v3, _ := s.build(ctn.resolver(ctn.ctx, nil), 0)
+	v4, _ := s.build(ctn.resolver(ctn.ctx, nil), 0)
+	assert.NotSame(t, v2, v3)
+	assert.Same(t, v3, v4)
+	assert.Equal(t, LifetimeSingleton, s.currentLifetime())
+}
+
+func TestService_PromoteToSingletonAfter_Concurrent(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddService(func() *testService {
+		return &testService{}
+	}).PromoteToSingletonAfter(5)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = ctn.GetService("di.testService")
+		}()
+	}
+	wg.Wait()
+
+	v := ctn.GetService("di.testService")
+	assert.Same(t, v, ctn.GetService("di.testService"))
+}
+
+func TestService_Build(t *testing.T) {
+	t.Run("Given Transient Service", func(t *testing.T) {
+		ctn := NewContainer()
+		ctor := func() (*testService, error) {
+			return &testService{
+				x: rand.Int(),
+			}, nil
+		}
+		s := &Service{
+			ctor:     ctor,
+			typ:      reflect.TypeOf(&testService{}),
+			lifetime: LifetimeTransient,
+		}
+
+		v1, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.NotNil(t, v1)
+		assert.Nil(t, err)
+
+		v2, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.NotNil(t, v2)
+		assert.Nil(t, err)
+
+		// Assert that the two builds are different
+		// as the service is transient.
+		assert.NotSame(t, v1, v2)
+	})
+
+	t.Run("Given Singleton Service", func(t *testing.T) {
+		ctn := NewContainer()
+		ctor := func() (*testService, error) {
+			return &testService{
+				x: rand.Int(),
+			}, nil
+		}
+		s := &Service{
+			ctor:     ctor,
+			typ:      reflect.TypeOf(&testService{}),
+			lifetime: LifetimeSingleton,
+		}
+
+		v1, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.NotNil(t, v1)
+		assert.Nil(t, err)
+
+		v2, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.NotNil(t, v2)
+		assert.Nil(t, err)
+
+		// Assert that the two builds are the same
+		// as the service is a singleton.
+		assert.Same(t, v1, v2)
+	})
+
+	t.Run("Where Ctor Returns Error", func(t *testing.T) {
+		ctn := NewContainer()
+		ctor := func() (*testService, error) {
+			return nil, assert.AnError
+		}
+		s := &Service{
+			ctor: ctor,
+			typ:  reflect.TypeOf(&testService{}),
+		}
+
+		v1, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.Nil(t, v1)
+		assert.Equal(t, assert.AnError, err)
+	})
+
+	t.Run("Where Service Has Dependency", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.addService(&Service{
+			name:     "di.testDependency",
+			typ:      reflect.TypeOf(&testDependency{}),
+			lifetime: LifetimeTransient,
+			ctor: func() *testDependency {
+				return &testDependency{}
+			},
+		})
+		ctor := func(d *testDependency) (*testService, error) {
+			return &testService{
+				dep: d,
+				x:   rand.Int(),
+			}, nil
+		}
+		s := &Service{
+			ctor:     ctor,
+			typ:      reflect.TypeOf(&testService{}),
+			lifetime: LifetimeSingleton,
+		}
+
+		v, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.NotNil(t, v)
+		assert.Nil(t, err)
+
+		ts := v.(*testService)
+		assert.NotNil(t, ts.dep)
+	})
+
+	t.Run("Where Service Cannot Find Dependency", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.addService(&Service{
+			name:     "di.testDependency",
+			typ:      reflect.TypeOf(&testDependency{}),
+			lifetime: LifetimeTransient,
+			ctor: func() *testDependency {
+				return &testDependency{}
+			},
+		})
+		ctor := func(d *testDependency, d2 *testDependency2) (*testService, error) {
+			return &testService{
+				dep: d,
+				x:   rand.Int(),
+			}, nil
+		}
+		s := &Service{
+			ctor:     ctor,
+			typ:      reflect.TypeOf(&testService{}),
+			lifetime: LifetimeSingleton,
+		}
+
+		v, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.Nil(t, v)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Where Service Dependency Failed To Build", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.addService(&Service{
+			name:     "di.testDependency",
+			typ:      reflect.TypeOf(&testDependency{}),
+			lifetime: LifetimeTransient,
+			ctor: func() (*testDependency, error) {
+				return nil, assert.AnError
+			},
+		})
+		ctor := func(d *testDependency, d2 *testDependency2) (*testService, error) {
+			return &testService{
+				dep: d,
+				x:   rand.Int(),
+			}, nil
+		}
+		s := &Service{
+			ctor:     ctor,
+			typ:      reflect.TypeOf(&testService{}),
+			lifetime: LifetimeSingleton,
+		}
+
+		v, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.Nil(t, v)
+		assert.Contains(t, err.Error(), assert.AnError.Error())
+	})
+
+	t.Run("Given Variadic Constructor", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddInstance(testStage("first")).As((*testMiddleware)(nil))
+		ctn.AddInstance(testStage("second")).As((*testMiddleware)(nil))
+
+		var names []string
+		s := ctn.AddService(func(d *testDependency, mw ...testMiddleware) *testService {
+			for _, m := range mw {
+				names = append(names, m.Name())
+			}
+			return &testService{dep: d}
+		})
+
+		v, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+		assert.Nil(t, err)
+		assert.NotNil(t, v.(*testService).dep)
+		assert.Equal(t, []string{"first", "second"}, names)
+	})
+}
+
+func BenchmarkService_Build_ConcurrentSingleton(b *testing.B) {
+	ctn := NewContainer()
+	s := ctn.AddService(func() *testService {
+		return &testService{}
+	}).AsSingleton()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = s.build(ctn.resolver(ctn.ctx, nil), 0)
+		}
+	})
+}
+
+func TestService_Build_ConcurrentSingleton(t *testing.T) {
+	builds := int32(0)
+	ctn := NewContainer()
+	s := ctn.AddService(func() *testService {
+		atomic.AddInt32(&builds, 1)
+		return &testService{}
+	}).AsSingleton()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.build(ctn.resolver(ctn.ctx, nil), 0)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), builds)
+}
+
+func TestService_Build_SingletonResolvesSingletonConcurrently(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddSingleton(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.AddSingleton(func() *testService {
+		// Resolve an unrelated singleton, and this one, from other
+		// goroutines, while this constructor is running. Neither
+		// should be blocked by this singleton being built.
+		done := make(chan interface{})
+		go func() {
+			done <- ctn.GetService("di.testDependency")
+		}()
+
+		select {
+		case v := <-done:
+			return &testService{dep: v.(*testDependency)}
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+
+	var wg sync.WaitGroup
+	values := make([]*testService, 5)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i] = ctn.GetService("di.testService").(*testService)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.NotNil(t, values[0])
+	assert.NotNil(t, values[0].dep)
+	for _, v := range values {
+		assert.Same(t, values[0], v)
+	}
+}
+
+func BenchmarkService_Build_Transient(b *testing.B) {
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency {
+		return &testDependency{}
+	})
+	ctn.AddService(func() *testDependency2 {
+		return &testDependency2{}
+	})
+	s := ctn.AddService(func(d *testDependency, d2 *testDependency2) (*testService, error) {
+		return &testService{dep: d}, nil
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.build(ctn.resolver(ctn.ctx, nil), 0)
+	}
+}
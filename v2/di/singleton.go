@@ -0,0 +1,75 @@
+package di
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// singleton holds the instance of a singleton service. The instance is
+// built at most once, using once, then read without locking.
+type singleton struct {
+	once  sync.Once
+	built atomic.Bool
+	impl  interface{}
+	err   error
+
+	// Whether the instance is owned by another container, which
+	// disposes it, such as one shared by Container.Merge.
+	borrowed bool
+}
+
+// newSingleton returns a singleton which has already been built, with impl.
+func newSingleton(impl interface{}) *singleton {
+	sg := &singleton{impl: impl}
+	sg.once.Do(func() {})
+	sg.built.Store(true)
+	return sg
+}
+
+// instance returns the service's singleton instance, if it has been built.
+func (s *Service) instance() interface{} {
+	if sg := s.inst.Load(); sg != nil && sg.built.Load() {
+		return sg.impl
+	}
+
+	return nil
+}
+
+// setInstance is used to set the service's singleton instance.
+func (s *Service) setInstance(impl interface{}) {
+	s.inst.Store(newSingleton(impl))
+}
+
+// buildSingleton is used to build the singleton instance of the service, using
+// build. Concurrent callers wait for the one build, rather than building their own.
+// If the build fails, the error is returned to each of them and the next call
+// will build again.
+//
+// No lock is held while build runs, so the constructor can resolve other
+// services, including from other goroutines. Only callers of this service
+// wait for it, and a dependency on itself is reported as a cycle first.
+func (s *Service) buildSingleton(build func() (interface{}, error)) (interface{}, error) {
+	for {
+		sg := s.inst.Load()
+		if sg == nil {
+			sg = &singleton{}
+			if !s.inst.CompareAndSwap(nil, sg) {
+				continue
+			}
+		}
+
+		sg.once.Do(func() {
+			sg.impl, sg.err = build()
+			if sg.err == nil {
+				sg.built.Store(true)
+			}
+		})
+
+		if sg.err != nil {
+			s.inst.CompareAndSwap(sg, nil)
+			return nil, sg.err
+		}
+
+		return sg.impl, nil
+	}
+}
@@ -0,0 +1,88 @@
+package di
+
+import (
+	"context"
+	"errors"
+)
+
+// Snapshot is a copy of the services registered in a Container, created
+// using Container.Snapshot, which can be restored using Container.Restore.
+type Snapshot struct {
+	services []*Service
+
+	// The singleton instance of each service when the snapshot was
+	// taken, used to find the singletons built since.
+	instances map[*Service]*singleton
+}
+
+// Snapshot is used to capture the services currently registered in the
+// container, so they can be restored later using Restore. This is useful in
+// tests, where a baseline set of services is registered once, then restored
+// between each case, rather than building a new container.
+//
+// Only the registrations are captured, not the services' configuration,
+// so changes made to a service after the snapshot is taken are kept.
+func (ctn *Container) Snapshot() *Snapshot {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	snap := &Snapshot{
+		services:  make([]*Service, len(ctn.services)),
+		instances: make(map[*Service]*singleton, len(ctn.services)),
+	}
+	copy(snap.services, ctn.services)
+	for _, s := range ctn.services {
+		snap.instances[s] = s.inst.Load()
+	}
+
+	return snap
+}
+
+// Restore is used to reset the services registered in the container to
+// those captured by snap. Services registered since the snapshot was taken
+// are removed, and singletons built since are disposed, in the same order
+// as Clean, so they are built again when next resolved.
+//
+// Any errors returned by a DisposeFuncE are joined and returned, however,
+// the services are restored regardless. Restore panics if the container
+// is frozen.
+func (ctn *Container) Restore(ctx context.Context, snap *Snapshot) error {
+	ctn.mu.Lock()
+	defer ctn.mu.Unlock()
+
+	ctn.checkFrozen("restore the container")
+
+	var errs []error
+	order := ctn.dependencyOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		s := order[i]
+		inst, ok := snap.instances[s]
+		if ok && s.inst.Load() == inst {
+			continue
+		}
+
+		if err := s.Dispose(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range ctn.services {
+		if _, ok := snap.instances[s]; !ok {
+			s.ctn = nil
+		}
+	}
+
+	started := ctn.started[:0]
+	for _, s := range ctn.started {
+		if s.instance() != nil {
+			started = append(started, s)
+		}
+	}
+	ctn.started = started
+
+	ctn.services = make([]*Service, len(snap.services))
+	copy(ctn.services, snap.services)
+	ctn.rebuildIndex()
+
+	return errors.Join(errs...)
+}
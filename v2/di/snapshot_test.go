@@ -0,0 +1,72 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_Snapshot(t *testing.T) {
+	t.Run("Given Services Registered Since", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency {
+			return &testDependency{}
+		})
+		snap := ctn.Snapshot()
+
+		ctn.AddService(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		})
+		assert.NotNil(t, GetService[*testService](ctn))
+
+		err := ctn.Restore(context.Background(), snap)
+		assert.NoError(t, err)
+		assert.Len(t, ctn.services, 1)
+		assert.NotNil(t, GetService[*testDependency](ctn))
+
+		_, err = ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+
+		// The snapshot should not be changed by later registrations.
+		ctn.AddService(func() *testDependency2 {
+			return &testDependency2{}
+		})
+		assert.NoError(t, ctn.Restore(context.Background(), snap))
+		assert.Len(t, ctn.services, 1)
+	})
+
+	t.Run("Given Singletons Built Since", func(t *testing.T) {
+		builds, disposed := 0, 0
+
+		ctn := NewContainer()
+		ctn.AddInstance(&testDependency2{})
+		ctn.AddSingleton(func() *testService {
+			builds++
+			return &testService{x: builds}
+		}).SetDispose(func(ctx context.Context, i interface{}) {
+			disposed++
+		})
+		inst := GetService[*testDependency2](ctn)
+		snap := ctn.Snapshot()
+
+		assert.Equal(t, 1, GetService[*testService](ctn).x)
+		assert.NoError(t, ctn.Restore(context.Background(), snap))
+		assert.Equal(t, 1, disposed)
+
+		// The singleton should be built again, whereas the
+		// instance built before the snapshot is kept.
+		assert.Equal(t, 2, GetService[*testService](ctn).x)
+		assert.Same(t, inst, GetService[*testDependency2](ctn))
+	})
+
+	t.Run("Where Container Is Frozen", func(t *testing.T) {
+		ctn := NewContainer()
+		snap := ctn.Snapshot()
+		ctn.Freeze()
+
+		assert.Panics(t, func() {
+			_ = ctn.Restore(context.Background(), snap)
+		})
+	})
+}
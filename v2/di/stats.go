@@ -0,0 +1,75 @@
+package di
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ServiceStats holds the number of times a service has been built, and
+// resolved using an existing instance, as returned by Container.Stats.
+type ServiceStats struct {
+	// The number of times the service's constructor succeeded.
+	Builds int64
+
+	// The number of times an existing instance was resolved, such as a
+	// singleton, or a scoped service already built in the Scope.
+	CacheHits int64
+
+	// When the service was last built, or zero if it hasn't been.
+	LastBuild time.Time
+}
+
+// serviceStats holds the counters of a service's ServiceStats, which
+// are updated atomically to avoid contention when resolving services.
+type serviceStats struct {
+	builds    atomic.Int64
+	hits      atomic.Int64
+	lastBuild atomic.Int64
+}
+
+// built is used to record that the service has been built.
+func (s *serviceStats) built() {
+	s.builds.Add(1)
+	s.lastBuild.Store(time.Now().UnixNano())
+}
+
+// reset is used to set each of the counters to zero.
+func (s *serviceStats) reset() {
+	s.builds.Store(0)
+	s.hits.Store(0)
+	s.lastBuild.Store(0)
+}
+
+// Stats returns the number of times each service in the container has been
+// built, and resolved from a cache, keyed by the service's name, since the
+// service was registered, or ResetStats was called. Where multiple services
+// have the same name, their stats are combined.
+func (ctn *Container) Stats() map[string]ServiceStats {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	stats := make(map[string]ServiceStats, len(ctn.services))
+	for _, s := range ctn.services {
+		st := stats[s.Name()]
+		st.Builds += s.stats.builds.Load()
+		st.CacheHits += s.stats.hits.Load()
+		if ns := s.stats.lastBuild.Load(); ns != 0 {
+			if t := time.Unix(0, ns); t.After(st.LastBuild) {
+				st.LastBuild = t
+			}
+		}
+		stats[s.Name()] = st
+	}
+
+	return stats
+}
+
+// ResetStats is used to reset the stats of each service in the container.
+func (ctn *Container) ResetStats() {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	for _, s := range ctn.services {
+		s.stats.reset()
+	}
+}
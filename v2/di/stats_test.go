@@ -0,0 +1,61 @@
+package di
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_Stats(t *testing.T) {
+	t.Run("Given Singleton", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func() *testService {
+			return &testService{}
+		})
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_ = ctn.GetService("di.testService")
+		}
+
+		st := ctn.Stats()["di.testService"]
+		assert.Equal(t, int64(1), st.Builds)
+		assert.Equal(t, int64(2), st.CacheHits)
+		assert.False(t, st.LastBuild.Before(start))
+	})
+
+	t.Run("Given Scoped And Transient Services", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddTransient(func() *testDependency2 {
+			return &testDependency2{}
+		})
+
+		scope := ctn.CreateScope()
+		for i := 0; i < 2; i++ {
+			_ = scope.GetService("di.testDependency")
+			_ = scope.GetService("di.testDependency2")
+		}
+
+		stats := ctn.Stats()
+		assert.Equal(t, int64(2), stats["di.testDependency2"].Builds)
+		assert.Zero(t, stats["di.testDependency2"].CacheHits)
+		assert.Equal(t, int64(1), stats["di.testDependency"].Builds)
+		assert.Equal(t, int64(1), stats["di.testDependency"].CacheHits)
+	})
+
+	t.Run("Where Stats Are Reset", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddSingleton(func() *testService {
+			return &testService{}
+		})
+		_ = ctn.GetService("di.testService")
+		_ = ctn.GetService("di.testService")
+
+		ctn.ResetStats()
+		assert.Equal(t, ServiceStats{}, ctn.Stats()["di.testService"])
+	})
+}
@@ -0,0 +1,73 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AddStruct adds a new service definition to the container, which is built by
+// populating the exported fields of a struct, rather than using a constructor
+// function. The prototype argument should be a zero value of the struct, or a
+// nil pointer to it, which determines the service's type.
+//
+// Each exported field is resolved in the same way as a field of an In struct,
+// using its `di` tag, so fields tagged with `di:"optional"` are left as their
+// zero value if they can not be resolved, fields tagged with `di:"name=..."`
+// are resolved by name, and fields tagged with `di:"-"` are ignored.
+//
+// If prototype is not a struct, or a pointer to one, AddStruct will panic.
+func (ctn *Container) AddStruct(prototype interface{}) *Service {
+	t := reflect.TypeOf(prototype)
+	st := t
+	if st != nil && st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if st == nil || st.Kind() != reflect.Struct {
+		panic(fmt.Errorf("container: %v is not a struct, or a pointer to a struct", t))
+	}
+
+	var (
+		fields []int
+		in     []reflect.Type
+		params []param
+	)
+
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		tag := parseFieldTag(f)
+		if !f.IsExported() || tag.ignore {
+			continue
+		}
+
+		fields = append(fields, i)
+		in = append(in, f.Type)
+		params = append(params, param{
+			typ:      f.Type,
+			factory:  isFactory(f.Type),
+			optional: isOptional(f.Type),
+			provider: isProvider(f.Type),
+			in:       isIn(f.Type),
+			zero:     tag.optional,
+			field:    f.Name,
+			name:     tag.name,
+		})
+	}
+
+	fn := reflect.MakeFunc(reflect.FuncOf(in, []reflect.Type{t}, false), func(args []reflect.Value) []reflect.Value {
+		v := reflect.New(st).Elem()
+		for i, idx := range fields {
+			v.Field(idx).Set(args[i])
+		}
+
+		if t.Kind() == reflect.Ptr {
+			return []reflect.Value{v.Addr()}
+		}
+
+		return []reflect.Value{v}
+	})
+
+	s := NewService(fn.Interface())
+	s.sig.Store(&constructor{fn: fn, params: params})
+
+	return ctn.addService(s)
+}
@@ -0,0 +1,103 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStructService struct {
+	Dep     *testDependency
+	Repo    testRepository   `di:"optional"`
+	Ignored *testDependency2 `di:"-"`
+	private *testDependency
+}
+
+func TestContainer_AddStruct(t *testing.T) {
+	t.Run("Given Pointer Prototype", func(t *testing.T) {
+		dep := &testDependency{}
+
+		ctn := NewContainer()
+		ctn.AddInstance(dep)
+		ctn.AddService(func() *testDependency2 {
+			return &testDependency2{}
+		})
+		s := ctn.AddStruct((*testStructService)(nil))
+		assert.Equal(t, "di.testStructService", s.Name())
+
+		v := ctn.GetService("di.testStructService").(*testStructService)
+		assert.Same(t, dep, v.Dep)
+		assert.Nil(t, v.Repo)
+		assert.Nil(t, v.Ignored)
+		assert.Nil(t, v.private)
+		assert.NoError(t, ctn.Validate())
+	})
+
+	t.Run("Given Struct Prototype", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddInstance(&testDependency{})
+		ctn.AddService(func() testRepository {
+			return &testSQLRepository{}
+		})
+		ctn.AddStruct(testStructService{})
+
+		v := ctn.GetService("di.testStructService").(testStructService)
+		assert.NotNil(t, v.Dep)
+		assert.IsType(t, &testSQLRepository{}, v.Repo)
+	})
+
+	t.Run("Where Required Field Can Not Be Resolved", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddStruct((*testStructService)(nil))
+
+		assert.PanicsWithError(t, "container: failed to build di.testStructService, service: failed to resolve field Dep of *di.testStructService, container: failed to resolve *di.testDependency", func() {
+			_ = ctn.GetService("di.testStructService")
+		})
+		assert.Error(t, ctn.Validate())
+	})
+
+	t.Run("Given Combined Tags", func(t *testing.T) {
+		type taggedStruct struct {
+			Primary *testDependency `di:"name=primary,optional"`
+			Missing *testDependency `di:"name=missing,optional"`
+		}
+
+		primary := &testDependency{}
+
+		ctn := NewContainer()
+		ctn.AddInstance(primary).SetName("primary")
+		ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("other")
+		ctn.AddStruct((*taggedStruct)(nil))
+
+		v := GetService[*taggedStruct](ctn)
+		assert.Same(t, primary, v.Primary)
+		assert.Nil(t, v.Missing)
+		assert.NoError(t, ctn.Validate())
+	})
+
+	t.Run("Given Provider Field", func(t *testing.T) {
+		type providerStruct struct {
+			Dep Provider[*testDependency]
+		}
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} })
+		ctn.AddStruct((*providerStruct)(nil))
+
+		v := GetService[*providerStruct](ctn)
+		dep, err := v.Dep.Get()
+		assert.NoError(t, err)
+		assert.NotNil(t, dep)
+	})
+
+	t.Run("Given Non-Struct Prototype", func(t *testing.T) {
+		ctn := NewContainer()
+
+		assert.Panics(t, func() {
+			ctn.AddStruct("test")
+		})
+		assert.Panics(t, func() {
+			ctn.AddStruct(nil)
+		})
+	})
+}
@@ -0,0 +1,85 @@
+package di
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxListedNames is the number of registered names, at most, which are listed
+// when a service is not found, if there are no names similar to the one given.
+const maxListedNames = 5
+
+// notFound returns the error for when there is no service with the given name.
+// To help find typos, the error suggests registered names which are similar to
+// name or, if there are few services registered, lists each of them.
+func (ctn *Container) notFound(name string) error {
+	names := ctn.names()
+
+	var similar []string
+	for _, n := range names {
+		if levenshtein(strings.ToLower(name), strings.ToLower(n)) <= 2+len(name)/8 {
+			similar = append(similar, n)
+		}
+	}
+
+	err := &notFoundError{name: name}
+	switch {
+	case len(similar) > 0:
+		err.hint = "did you mean " + strings.Join(similar, " or ") + "?"
+	case len(names) > 0 && len(names) <= maxListedNames:
+		err.hint = "registered services are " + strings.Join(names, ", ")
+	}
+
+	return err
+}
+
+// names returns the sorted, distinct names of the services
+// registered in the container, and its parent containers.
+func (ctn *Container) names() []string {
+	seen := make(map[string]bool)
+	for c := ctn; c != nil; c = c.parent {
+		c.mu.RLock()
+		for n, svcs := range c.byName {
+			if len(svcs) > 0 {
+				seen[n] = true
+			}
+		}
+		c.mu.RUnlock()
+	}
+
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// levenshtein returns the number of single character edits
+// needed to change the string a into b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(br)]
+}
@@ -0,0 +1,82 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// WithTimeout configures the service's constructor to be abandoned if it
+// does not return within d, such as when it blocks dialing a connection, in
+// which case the error returned wraps context.DeadlineExceeded. A constructor
+// with a context.Context parameter is given a context which is cancelled once
+// d has elapsed, derived from the resolution's context, so it can return early.
+//
+// The timeout applies to the constructor only, as its dependencies are
+// resolved before it's called. An abandoned constructor is left to return
+// in its own goroutine, and its result is discarded, so a singleton is
+// built again when next resolved.
+func (s *Service) WithTimeout(d time.Duration) *Service {
+	s.timeout = d
+
+	return s
+}
+
+// contextResolver wraps a resolver, to provide ctx in place of the
+// resolution's context.Context to the constructor being called.
+type contextResolver struct {
+	resolver
+	ctx context.Context
+}
+
+func (r contextResolver) Resolve(t reflect.Type) (interface{}, error) {
+	if t == contextType {
+		return r.ctx, nil
+	}
+
+	return r.resolver.Resolve(t)
+}
+
+func (r contextResolver) Context() context.Context {
+	return r.ctx
+}
+
+// callTimeout is used to invoke the service's constructor, in the same way as
+// call, but returns an error if it doesn't return within the service's timeout.
+// The constructor's arguments are resolved before it's called in another
+// goroutine, so an abandoned constructor doesn't resolve services once the
+// caller has returned.
+func (s *Service) callTimeout(r resolver) (interface{}, error) {
+	if s.timeout <= 0 {
+		return s.call(r)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	c := s.constructor()
+	args, err := resolveArgs(contextResolver{resolver: r, ctx: ctx}, c.params, nil, s.typ)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		impl interface{}
+		err  error
+	}
+
+	// Buffered, so the goroutine doesn't block if the result is abandoned.
+	done := make(chan result, 1)
+	go func() {
+		impl, err := s.callResolved(ctx, c, args)
+		done <- result{impl: impl, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.impl, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("service: %s did not build within %s, %w", s.Name(), s.timeout, ctx.Err())
+	}
+}
@@ -0,0 +1,82 @@
+package di
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_WithTimeout(t *testing.T) {
+	t.Run("Where Constructor Is Too Slow", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		var calls atomic.Int32
+		ctn := NewContainer()
+		ctn.AddSingleton(func() *testService {
+			n := calls.Add(1)
+			if n == 1 {
+				<-release
+			}
+			return &testService{x: int(n)}
+		}).WithTimeout(10 * time.Millisecond)
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "service: di.testService did not build within 10ms")
+
+		// The abandoned constructor should not be used as the singleton.
+		v := ctn.GetService("di.testService").(*testService)
+		assert.Equal(t, 2, v.x)
+	})
+
+	t.Run("Where Constructor Uses Context", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func(ctx context.Context) (*testService, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).WithTimeout(10 * time.Millisecond)
+
+		_, err := ctn.TryGetService("di.testService")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Where Constructor Is Abandoned In Scope", func(t *testing.T) {
+		release := make(chan struct{})
+
+		var calls atomic.Int32
+		ctn := NewContainer()
+		ctn.AddScoped(func() *testDependency { return &testDependency{} })
+		ctn.AddScoped(func(dep *testDependency) *testService {
+			if calls.Add(1) == 1 {
+				<-release
+			}
+			return &testService{dep: dep}
+		}).WithTimeout(10 * time.Millisecond)
+		scope := ctn.CreateScope()
+
+		assert.Panics(t, func() {
+			_ = scope.GetService("di.testService")
+		})
+
+		// The abandoned constructor returns while the scope is in use.
+		close(release)
+		dep := scope.GetService("di.testDependency")
+		v := scope.GetService("di.testService").(*testService)
+		assert.Same(t, dep, v.dep)
+	})
+
+	t.Run("Where Constructor Is Fast", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testService {
+			return &testService{x: 1}
+		}).WithTimeout(time.Second)
+
+		v, err := ctn.TryGetService("di.testService")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v.(*testService).x)
+	})
+}
@@ -1,10 +1,30 @@
 package di
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+)
 
 // GetService is generic function used to get a service
 // from the given ServiceProvider.
+//
+// Where the ServiceProvider is a Container or Scope, or another Resolver, the
+// service is resolved by the type T, so types with the same name, from different
+// packages, are resolved independently. Otherwise, it is resolved by the name of T.
+// Middleware added to a Container by Use is given the name of the service.
 func GetService[T any](sp ServiceProvider) T {
+	if p, ok := sp.(typedProvider); ok {
+		return p.getServiceByType(reflect.TypeOf((*T)(nil)).Elem()).(T)
+	}
+
+	if r, ok := sp.(Resolver); ok {
+		v, err := r.Resolve(reflect.TypeOf((*T)(nil)).Elem())
+		if err != nil {
+			panic(err)
+		}
+		return v.(T)
+	}
+
 	t := reflect.TypeOf(new(T))
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -20,3 +40,156 @@ func GetService[T any](sp ServiceProvider) T {
 func GetServiceByName[T any](sp ServiceProvider, name string) T {
 	return sp.GetService(name).(T)
 }
+
+// GetServices is a generic function used to get each of the services of type
+// T from the given ServiceProvider, in registration order, as a typed slice.
+// Within a Scope, scoped services are resolved from the Scope.
+//
+// The ServiceProvider must implement GetServices(reflect.Type), as Container
+// and Scope do, otherwise GetServices will panic.
+func GetServices[T any](sp ServiceProvider) []T {
+	p, ok := sp.(servicesProvider)
+	if !ok {
+		panic(fmt.Errorf("container: %T does not support resolving multiple services", sp))
+	}
+
+	svcs := p.GetServices(reflect.TypeOf((*T)(nil)).Elem())
+	vs := make([]T, len(svcs))
+	for i, v := range svcs {
+		vs[i] = v.(T)
+	}
+	return vs
+}
+
+// GetKeyedService is a generic function used to get the service of type T,
+// registered with the given key using Service.WithKey, from the given
+// ServiceProvider. This allows multiple services of the same type to be
+// retrieved by type:
+//
+//	cache := di.GetKeyedService[*redis.Client](ctn, "cache")
+//
+// The ServiceProvider must implement KeyedProvider, otherwise GetKeyedService will panic.
+func GetKeyedService[T any](sp ServiceProvider, key string) T {
+	p, ok := sp.(KeyedProvider)
+	if !ok {
+		panic(fmt.Errorf("container: %T does not support keyed services", sp))
+	}
+
+	return p.GetKeyedService(reflect.TypeOf((*T)(nil)).Elem(), key).(T)
+}
+
+// GetPipeline is a generic function used to get the services in a group
+// from the given ServiceProvider, sorted by their order, as a typed slice.
+// This is intended for middleware or interceptor chains, where the order
+// of the stages matters.
+//
+// The ServiceProvider must implement GroupProvider, otherwise GetPipeline will panic.
+func GetPipeline[T any](sp ServiceProvider, group string) []T {
+	gp, ok := sp.(GroupProvider)
+	if !ok {
+		panic(fmt.Errorf("container: %T does not support groups", sp))
+	}
+
+	svcs := gp.GetGroup(group)
+	stages := make([]T, len(svcs))
+	for i, v := range svcs {
+		stages[i] = v.(T)
+	}
+	return stages
+}
+
+// Register is a generic function used to add a service to the container,
+// where the service's type and name are derived from T, rather than the
+// return type of ctor. This allows a concrete constructor to be registered
+// under an interface: Register[Repository](ctn, newSQLRepository).
+//
+// If the return type of ctor is not assignable to T, Register will panic.
+func Register[T any](ctn *Container, ctor interface{}) *Service {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	s := NewService(ctor)
+	if !s.typ.AssignableTo(t) {
+		panic(fmt.Errorf("service: %s is not assignable to %s", s.typ.String(), t.String()))
+	}
+
+	s.typ = t
+	s.name = serviceName(t)
+
+	return ctn.addService(s)
+}
+
+// AddSingleton is a generic function used to add a singleton service to the
+// container, in the same way as Register, so the service is resolved by T.
+func AddSingleton[T any](ctn *Container, ctor interface{}) *Service {
+	return Register[T](ctn, ctor).AsSingleton()
+}
+
+// AddTransient is a generic function used to add a transient service to the
+// container, in the same way as Register, so the service is resolved by T.
+func AddTransient[T any](ctn *Container, ctor interface{}) *Service {
+	return Register[T](ctn, ctor).AsTransient()
+}
+
+// AddScoped is a generic function used to add a scoped service to the
+// container, in the same way as Register, so the service is resolved by T.
+func AddScoped[T any](ctn *Container, ctor interface{}) *Service {
+	return Register[T](ctn, ctor).AsScoped()
+}
+
+// InvokeE is used to call the function fn, where each of its parameters is
+// resolved from the container, in the same way as a constructor's, including
+// context.Context, Optional and In parameters. The values returned by fn are
+// returned, in order, except a trailing error, which is returned as the error:
+//
+//	results, err := di.InvokeE(ctn, func(db *DB, l Logger) *App { ... })
+//
+// If fn is not a func, a parameter can not be resolved or fn panics, an
+// error is returned.
+func InvokeE(ctn *Container, fn interface{}) (results []interface{}, err error) {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("container: %v is not a func", t)
+	}
+
+	c := newConstructor(fn)
+	args, err := resolveArgs(ctn.resolver(ctn.ctx, nil), c.params, nil, t)
+	if err != nil {
+		return nil, err
+	}
+
+	call := c.fn.Call
+	if c.variadic {
+		call = c.fn.CallSlice
+	}
+
+	if !ctn.noRecover {
+		defer func() {
+			if r := recover(); r != nil {
+				results, err = nil, fmt.Errorf("container: %s panicked, %v", t.String(), r)
+			}
+		}()
+	}
+
+	out := call(args)
+	if n := t.NumOut(); n > 0 && t.Out(n-1) == errorType {
+		if e := out[n-1]; !e.IsNil() {
+			return nil, e.Interface().(error)
+		}
+		out = out[:n-1]
+	}
+
+	results = make([]interface{}, len(out))
+	for i, v := range out {
+		results[i] = v.Interface()
+	}
+	return results, nil
+}
+
+// MustInvoke is used to call the function fn, in the same way as InvokeE,
+// but panics if an error is returned.
+func MustInvoke(ctn *Container, fn interface{}) []interface{} {
+	results, err := InvokeE(ctn, fn)
+	if err != nil {
+		panic(err)
+	}
+	return results
+}
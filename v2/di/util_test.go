@@ -1,8 +1,12 @@
 package di
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
+	one "github.com/reecerussell/simple-di/v2/di/internal/testpkg/one/config"
+	two "github.com/reecerussell/simple-di/v2/di/internal/testpkg/two/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,6 +34,47 @@ func TestGetService_GivenPointerType_ReturnsService(t *testing.T) {
 	assert.NotNil(t, v)
 }
 
+func TestGetService_GivenTypesWithSameName_ReturnsEachService(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddService(func() *one.Config {
+		return &one.Config{Name: "one"}
+	})
+	ctn.AddService(func() *two.Config {
+		return &two.Config{Name: "two"}
+	})
+
+	assert.Equal(t, "one", GetService[*one.Config](ctn).Name)
+	assert.Equal(t, "two", GetService[*two.Config](ctn).Name)
+	assert.Equal(t, "one", GetService[*one.Config](ctn.CreateScope()).Name)
+	assert.Equal(t, "two", GetService[*two.Config](ctn.CreateScope()).Name)
+}
+
+func TestGetService_GivenMiddleware_CallsMiddleware(t *testing.T) {
+	stub := &testService{x: 1}
+	var names []string
+
+	ctn := NewContainer()
+	ctn.AddSingleton(func() *testService { return &testService{} })
+	ctn.AddSingleton(func() *testDependency { return &testDependency{} })
+	ctn.AddService(func() *one.Config { return &one.Config{Name: "one"} })
+	ctn.AddService(func() *two.Config { return &two.Config{Name: "two"} })
+	ctn.Use(func(next func(name string) interface{}) func(name string) interface{} {
+		return func(name string) interface{} {
+			names = append(names, name)
+			if name == "di.testService" {
+				return stub
+			}
+			return next(name)
+		}
+	})
+
+	assert.Same(t, stub, GetService[*testService](ctn))
+	assert.Same(t, stub, GetService[*testService](ctn.CreateScope()))
+	assert.NotNil(t, GetService[*testDependency](ctn))
+	assert.Equal(t, "two", GetService[*two.Config](ctn).Name)
+	assert.Equal(t, []string{"di.testService", "di.testService", "di.testDependency", "config.Config"}, names)
+}
+
 func TestGetService_GivenInterfaceType_ReturnsService(t *testing.T) {
 	ctor := func() TestService {
 		return testService{}
@@ -41,3 +86,205 @@ func TestGetService_GivenInterfaceType_ReturnsService(t *testing.T) {
 	v := GetService[TestService](ctn)
 	assert.NotNil(t, v)
 }
+
+type testRepository interface {
+	Get() string
+}
+
+type testSQLRepository struct{}
+
+func (*testSQLRepository) Get() string { return "sql" }
+
+type testMemoryRepository struct{}
+
+func (*testMemoryRepository) Get() string { return "memory" }
+
+func TestRegister(t *testing.T) {
+	t.Run("Given Assignable Ctor", func(t *testing.T) {
+		ctor := func() *testSQLRepository {
+			return &testSQLRepository{}
+		}
+
+		ctn := NewContainer()
+		s := Register[testRepository](ctn, ctor)
+		assert.Equal(t, "di.testRepository", s.Name())
+
+		v := GetService[testRepository](ctn)
+		assert.Equal(t, "sql", v.Get())
+	})
+
+	t.Run("Given Unassignable Ctor", func(t *testing.T) {
+		ctor := func() *testDependency {
+			return &testDependency{}
+		}
+
+		ctn := NewContainer()
+		assert.Panics(t, func() {
+			_ = Register[testRepository](ctn, ctor)
+		})
+		assert.Len(t, ctn.services, 0)
+	})
+}
+
+func TestAddLifetime(t *testing.T) {
+	t.Run("Given Singleton", func(t *testing.T) {
+		ctn := NewContainer()
+		s := AddSingleton[testRepository](ctn, func() *testSQLRepository {
+			return &testSQLRepository{}
+		})
+		assert.Equal(t, LifetimeSingleton, s.lifetime)
+		assert.Equal(t, "di.testRepository", s.Name())
+
+		v := GetService[testRepository](ctn)
+		assert.Equal(t, "sql", v.Get())
+		assert.Same(t, v, GetService[testRepository](ctn))
+		assert.Panics(t, func() {
+			_ = GetService[*testSQLRepository](ctn)
+		})
+	})
+
+	t.Run("Given Transient", func(t *testing.T) {
+		ctn := NewContainer()
+		s := AddTransient[testRepository](ctn, func() *testMemoryRepository {
+			return &testMemoryRepository{}
+		})
+		assert.Equal(t, LifetimeTransient, s.lifetime)
+		assert.Equal(t, "memory", GetService[testRepository](ctn).Get())
+	})
+
+	t.Run("Given Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		s := AddScoped[testRepository](ctn, func() *testMemoryRepository {
+			return &testMemoryRepository{}
+		})
+		assert.Equal(t, LifetimeScoped, s.lifetime)
+		assert.Equal(t, "memory", GetService[testRepository](ctn.CreateScope()).Get())
+	})
+
+	t.Run("Given Unassignable Ctor", func(t *testing.T) {
+		ctn := NewContainer()
+		assert.Panics(t, func() {
+			_ = AddSingleton[testRepository](ctn, func() *testDependency {
+				return &testDependency{}
+			})
+		})
+		assert.Len(t, ctn.services, 0)
+	})
+}
+
+type testMiddleware interface {
+	Name() string
+}
+
+type testStage string
+
+func (s testStage) Name() string { return string(s) }
+
+func TestGetPipeline(t *testing.T) {
+	ctn := NewContainer()
+	Register[testMiddleware](ctn, func() testStage { return "first" }).InGroup("pipeline")
+	Register[testMiddleware](ctn, func() testStage { return "second" }).InGroup("pipeline")
+	Register[testMiddleware](ctn, func() testStage { return "other" })
+	Register[testMiddleware](ctn, func() testStage { return "third" }).InGroup("pipeline")
+
+	stages := GetPipeline[testMiddleware](ctn, "pipeline")
+	assert.Len(t, stages, 3)
+	assert.Equal(t, "first", stages[0].Name())
+	assert.Equal(t, "second", stages[1].Name())
+	assert.Equal(t, "third", stages[2].Name())
+
+	// Should also resolve in the same order from a scope.
+	stages = GetPipeline[testMiddleware](ctn.CreateScope(), "pipeline")
+	assert.Len(t, stages, 3)
+	assert.Equal(t, "third", stages[2].Name())
+
+	// Stages with an explicit order should be sorted first.
+	Register[testMiddleware](ctn, func() testStage { return "zeroth" }).InGroup("pipeline").WithOrder(-1)
+	stages = GetPipeline[testMiddleware](ctn, "pipeline")
+	assert.Len(t, stages, 4)
+	assert.Equal(t, "zeroth", stages[0].Name())
+	assert.Equal(t, "third", stages[3].Name())
+
+	// Assignable services should be ordered in the same way.
+	vs := ctn.GetServicesAssignable(reflect.TypeOf((*testMiddleware)(nil)).Elem())
+	assert.Equal(t, testStage("zeroth"), vs[0])
+}
+
+func TestGetServices(t *testing.T) {
+	ctn := NewContainer()
+	Register[testRepository](ctn, func() *testSQLRepository { return &testSQLRepository{} })
+	Register[testRepository](ctn, func() *testMemoryRepository { return &testMemoryRepository{} })
+
+	repos := GetServices[testRepository](ctn)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, "sql", repos[0].Get())
+	assert.Equal(t, "memory", repos[1].Get())
+}
+
+func TestInvokeE(t *testing.T) {
+	type ctxKey struct{}
+
+	newContainer := func() *Container {
+		ctx := context.WithValue(context.Background(), ctxKey{}, 1)
+		ctn := NewContainer(WithContext(ctx))
+		ctn.AddSingleton(func() *testDependency {
+			return &testDependency{}
+		})
+		ctn.AddSingleton(func() *testDependency2 {
+			return &testDependency2{}
+		})
+		return ctn
+	}
+
+	t.Run("Given Injected Args", func(t *testing.T) {
+		ctn := newContainer()
+
+		results, err := InvokeE(ctn, func(ctx context.Context, d *testDependency, d2 *testDependency2) *testService {
+			assert.Same(t, ctn.GetService("di.testDependency2"), d2)
+			return &testService{dep: d, x: ctx.Value(ctxKey{}).(int)}
+		})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+
+		v := results[0].(*testService)
+		assert.Same(t, ctn.GetService("di.testDependency"), v.dep)
+		assert.Equal(t, 1, v.x)
+	})
+
+	t.Run("Where Func Returns Error", func(t *testing.T) {
+		ctn := newContainer()
+
+		results, err := InvokeE(ctn, func(d *testDependency) (*testService, error) {
+			return nil, assert.AnError
+		})
+		assert.Nil(t, results)
+		assert.Equal(t, assert.AnError, err)
+
+		assert.Panics(t, func() {
+			MustInvoke(ctn, func(d *testDependency) error {
+				return assert.AnError
+			})
+		})
+	})
+
+	t.Run("Where Arg Can Not Be Resolved", func(t *testing.T) {
+		ctn := newContainer()
+
+		_, err := InvokeE(ctn, func(s *testService) {})
+		assert.ErrorIs(t, err, ErrServiceNotFound)
+	})
+
+	t.Run("Given Non Func", func(t *testing.T) {
+		_, err := InvokeE(newContainer(), "not a func")
+		assert.EqualError(t, err, "container: string is not a func")
+	})
+
+	t.Run("Where Func Has No Results", func(t *testing.T) {
+		called := false
+		results := MustInvoke(newContainer(), func(d *testDependency) {
+			called = true
+		})
+		assert.True(t, called)
+		assert.Empty(t, results)
+	})
+}
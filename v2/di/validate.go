@@ -0,0 +1,138 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate is used to check the services in the container are configured
+// correctly, without building them. An error is returned describing each
+// problem found.
+//
+// Each constructor parameter is checked to ensure it can be resolved, either
+// by a single service of its type, or as a context.Context, Optional or slice.
+//
+// A captive dependency is reported where a singleton service depends on a
+// scoped service, either directly or through transient services, as the
+// singleton would capture the instance from the first scope it's built in.
+func (ctn *Container) Validate() error {
+	_, err := ctn.ValidateWithWarnings()
+	return err
+}
+
+// ValidateWithWarnings is used to validate the services in the container, in
+// the same way as Validate, whilst also returning warnings about services
+// which are valid, but may not be configured as intended.
+//
+// A warning is returned where a transient service depends on a scoped service,
+// as the transient service can then only be resolved from a Scope.
+func (ctn *Container) ValidateWithWarnings() (warnings []string, err error) {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	var errs []error
+	for _, s := range ctn.services {
+		if s.lifetime == LifetimeTransient {
+			for _, ds := range ctn.scopedDependencies(s) {
+				warnings = append(warnings, fmt.Sprintf("container: transient %s depends on scoped %s, so can only be resolved from a scope", s.Name(), ds.Name()))
+			}
+		}
+
+		for _, dep := range ctn.dependencies(s) {
+			switch {
+			case len(dep.svcs) == 0 && !dep.optional:
+				errs = append(errs, fmt.Errorf("container: %s depends on %s, which can not be resolved", s.Name(), dep.typ.String()))
+			case len(dep.svcs) > 1 && !dep.multi:
+				errs = append(errs, fmt.Errorf("container: %s depends on %s, which is ambiguous", s.Name(), dep.typ.String()))
+			}
+		}
+
+		if s.lifetime != LifetimeSingleton {
+			continue
+		}
+
+		for _, c := range ctn.captives(s, map[*Service]bool{}) {
+			errs = append(errs, fmt.Errorf("container: singleton %s depends on scoped %s", s.Name(), c.Name()))
+		}
+	}
+
+	return warnings, errors.Join(errs...)
+}
+
+// scopedDependencies returns the scoped services which s depends on directly.
+// The caller must hold the read lock.
+func (ctn *Container) scopedDependencies(s *Service) []*Service {
+	var scoped []*Service
+	for _, dep := range ctn.dependencies(s) {
+		if dep.lazy {
+			continue
+		}
+
+		for _, ds := range dep.svcs {
+			if ds.lifetime == LifetimeScoped {
+				scoped = append(scoped, ds)
+			}
+		}
+	}
+
+	return scoped
+}
+
+// captives returns the scoped services which s depends on. Transient
+// dependencies are walked, as they would be built once, for the singleton.
+func (ctn *Container) captives(s *Service, seen map[*Service]bool) []*Service {
+	var scoped []*Service
+	for _, dep := range ctn.dependencies(s) {
+		if dep.lazy {
+			continue
+		}
+
+		for _, ds := range dep.svcs {
+			if seen[ds] {
+				continue
+			}
+			seen[ds] = true
+
+			switch ds.lifetime {
+			case LifetimeScoped:
+				scoped = append(scoped, ds)
+			case LifetimeTransient:
+				scoped = append(scoped, ctn.captives(ds, seen)...)
+			}
+		}
+	}
+
+	return scoped
+}
+
+// UnusedServices returns the names of the services which are not a dependency
+// of any other service in the container, in registration order. No services
+// are built.
+//
+// This is a best-effort report, as services resolved directly, such as by
+// GetService, can not be known, so the services returned include the roots
+// of the application, as well as any which are unused and could be removed.
+func (ctn *Container) UnusedServices() []string {
+	ctn.mu.RLock()
+	defer ctn.mu.RUnlock()
+
+	used := make(map[*Service]bool, len(ctn.services))
+	for _, s := range ctn.services {
+		for _, dep := range ctn.dependencies(s) {
+			for _, ds := range dep.svcs {
+				if ds != s {
+					used[ds] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0)
+	for _, s := range ctn.services {
+		if !used[s] {
+			names = append(names, s.Name())
+		}
+	}
+
+	return names
+}
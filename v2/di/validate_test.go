@@ -0,0 +1,166 @@
+package di
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer_Validate(t *testing.T) {
+	t.Run("Where Singleton Depends On Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsScoped()
+		ctn.AddService(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		}).AsSingleton().SetName("MyService")
+
+		err := ctn.Validate()
+		assert.EqualError(t, err, "container: singleton MyService depends on scoped di.testDependency")
+	})
+
+	t.Run("Where Singleton Depends On Scoped Through Transient", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsScoped()
+		ctn.AddService(func(d *testDependency) *testDependency2 { return &testDependency2{} }).AsTransient()
+		ctn.AddService(func(d *testDependency2) *testService {
+			return &testService{}
+		}).AsSingleton().SetName("MyService")
+
+		err := ctn.Validate()
+		assert.EqualError(t, err, "container: singleton MyService depends on scoped di.testDependency")
+	})
+
+	t.Run("Where Singleton Depends On Scoped Factory", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsScoped()
+		ctn.AddService(func(f func() *testDependency) *testService {
+			return &testService{}
+		}).AsSingleton()
+
+		assert.Nil(t, ctn.Validate())
+	})
+
+	t.Run("Where Lifetimes Are Valid", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsSingleton()
+		ctn.AddService(func(d *testDependency) *testDependency2 { return &testDependency2{} }).AsScoped()
+		ctn.AddService(func(d *testDependency2) *testService {
+			return &testService{}
+		}).AsTransient()
+
+		assert.Nil(t, ctn.Validate())
+	})
+
+	t.Run("Where Child Depends On Parent Service", func(t *testing.T) {
+		parent := NewContainer()
+		parent.AddSingleton(func() *testDependency { return &testDependency{} })
+
+		child := parent.CreateChild()
+		child.AddService(func(d *testDependency, ds []*testDependency) *testService {
+			return &testService{dep: d}
+		})
+
+		assert.Nil(t, child.Validate())
+	})
+
+	t.Run("Where Child Depends On Missing Service", func(t *testing.T) {
+		child := NewContainer().CreateChild()
+		child.AddService(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		})
+
+		assert.EqualError(t, child.Validate(), "container: di.testService depends on *di.testDependency, which can not be resolved")
+	})
+}
+
+func TestContainer_ValidateWithWarnings(t *testing.T) {
+	t.Run("Where Transient Depends On Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsScoped()
+		ctn.AddService(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		}).AsTransient().SetName("MyService")
+
+		warnings, err := ctn.ValidateWithWarnings()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"container: transient MyService depends on scoped di.testDependency, so can only be resolved from a scope",
+		}, warnings)
+	})
+
+	t.Run("Where Transient Depends On Scoped Factory", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsScoped()
+		ctn.AddService(func(f func() *testDependency) *testService {
+			return &testService{}
+		}).AsTransient()
+
+		warnings, err := ctn.ValidateWithWarnings()
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("Where Singleton Depends On Scoped", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).AsScoped()
+		ctn.AddService(func(d *testDependency) *testService {
+			return &testService{dep: d}
+		}).AsSingleton()
+
+		warnings, err := ctn.ValidateWithWarnings()
+		assert.Error(t, err)
+		assert.Empty(t, warnings)
+	})
+}
+
+func TestContainer_Validate_Resolvable(t *testing.T) {
+	t.Run("Where Dependencies Are Resolvable", func(t *testing.T) {
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} })
+		ctn.AddService(func(ctx context.Context, d *testDependency, o Optional[*testDependency2], mw []testMiddleware) *testService {
+			return &testService{}
+		})
+
+		assert.Nil(t, ctn.Validate())
+	})
+
+	t.Run("Where Dependencies Are Not Resolvable", func(t *testing.T) {
+		called := false
+
+		ctn := NewContainer()
+		ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("First")
+		ctn.AddService(func() *testDependency { return &testDependency{} }).SetName("Second")
+		ctn.AddService(func(d *testDependency, d2 *testDependency2) *testService {
+			called = true
+			return &testService{}
+		}).SetName("MyService")
+		ctn.AddService(func(f func() *testService, r testRepository) *testSQLRepository {
+			called = true
+			return &testSQLRepository{}
+		}).SetName("MyRepository")
+
+		err := ctn.Validate()
+		assert.EqualError(t, err, strings.Join([]string{
+			"container: MyService depends on *di.testDependency, which is ambiguous",
+			"container: MyService depends on *di.testDependency2, which can not be resolved",
+			"container: MyRepository depends on di.testRepository, which can not be resolved",
+		}, "\n"))
+
+		// Constructors should not be called.
+		assert.False(t, called)
+	})
+}
+
+func TestContainer_UnusedServices(t *testing.T) {
+	ctn := NewContainer()
+	ctn.AddService(func() *testDependency { return &testDependency{} })
+	ctn.AddService(func() *testDependency2 { return &testDependency2{} }).SetName("Orphan")
+	ctn.AddService(func(d *testDependency, f func() *testSQLRepository) *testService {
+		return &testService{dep: d}
+	}).SetName("Root")
+	ctn.AddService(func() *testSQLRepository { return &testSQLRepository{} })
+
+	assert.Equal(t, []string{"Orphan", "Root"}, ctn.UnusedServices())
+}